@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/flashbots/mev-boost/config"
+	"github.com/sirupsen/logrus"
+)
+
+// errUsage is the result of a command invoked with invalid flags or arguments, see exitCode
+var errUsage = errors.New("usage error")
+
+// exitCode maps the error returned by a command to its exit code. Scripts depend on the exit codes, so they are only
+// defined here, and must not change: 0 success, 1 other errors, 2 usage error. Commands which need to report other
+// kinds of failures add their own exit codes here.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// commandResult is the result of a command, printed as text or, with -output json, as a JSON document
+type commandResult interface {
+	text() string
+}
+
+// commandDocument is the JSON document printed by a command with -output json. Errors are printed to stderr.
+type commandDocument struct {
+	Command  string        `json:"command"`
+	ExitCode int           `json:"exit_code"`
+	Result   commandResult `json:"result"`
+}
+
+// command is a subcommand of mev-boost, invoked as `mev-boost <name> [flags]`. Each command has its own flag set
+// and help text. Without a subcommand, mev-boost starts the service with the flags defined in main.go.
+type command struct {
+	name    string
+	summary string
+
+	// setup registers the flags of the command, and returns the function which runs it after the flags are parsed.
+	// The result may be nil if the command failed.
+	setup func(fs *flag.FlagSet) func() (commandResult, error)
+}
+
+type commandList []commandSummary
+
+type commandSummary struct {
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+}
+
+func (l commandList) text() string {
+	var b strings.Builder
+	b.WriteString("Usage: mev-boost [command] [flags]\n\nWithout a command, mev-boost starts the service, see 'mev-boost -help'.\n\nCommands:\n")
+	for _, cmd := range l {
+		fmt.Fprintf(&b, "  %-10s %s\n", cmd.Name, cmd.Summary)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+type versionResult struct {
+	Version string `json:"version"`
+}
+
+func (r versionResult) text() string {
+	return "mev-boost " + r.Version
+}
+
+// commands are the subcommands, set in init as the help command refers to the list
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name:    "help",
+			summary: "list the commands",
+			setup: func(fs *flag.FlagSet) func() (commandResult, error) {
+				return func() (commandResult, error) {
+					list := make(commandList, len(commands))
+					for i, cmd := range commands {
+						list[i] = commandSummary{Name: cmd.name, Summary: cmd.summary}
+					}
+					return list, nil
+				}
+			},
+		},
+		{
+			name:    "version",
+			summary: "print the version",
+			setup: func(fs *flag.FlagSet) func() (commandResult, error) {
+				return func() (commandResult, error) {
+					return versionResult{Version: config.Version}, nil
+				}
+			},
+		},
+	}
+}
+
+// findCommand returns the command named by the first argument. Arguments starting with a dash are flags of the
+// default command, so existing invocations are never mistaken for a command.
+func findCommand(args []string) (*command, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	for i := range commands {
+		if commands[i].name == args[0] {
+			return &commands[i], true
+		}
+	}
+	return nil, false
+}
+
+// runCommand parses the flags of the command and runs it, returning the exit code. The result is printed to stdout,
+// usage and errors to stderr.
+func runCommand(cmd *command, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mev-boost "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of mev-boost %s: %s\n", cmd.name, cmd.summary)
+		fs.PrintDefaults()
+	}
+	output := fs.String("output", "text", "output format: text or json")
+	run := cmd.setup(fs)
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return exitCode(nil)
+	} else if err != nil {
+		return exitCode(errUsage)
+	}
+	if *output != "text" && *output != "json" {
+		fmt.Fprintf(stderr, "invalid value %q for flag -output: must be text or json\n", *output)
+		fs.Usage()
+		return exitCode(errUsage)
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return exitCode(errUsage)
+	}
+
+	result, err := run()
+	code := exitCode(err)
+	if err != nil {
+		fmt.Fprintf(stderr, "mev-boost %s: %v\n", cmd.name, err)
+	}
+	if *output == "json" {
+		doc := commandDocument{Command: cmd.name, ExitCode: code, Result: result}
+		if err := json.NewEncoder(stdout).Encode(doc); err != nil {
+			fmt.Fprintf(stderr, "mev-boost %s: %v\n", cmd.name, err)
+		}
+	} else if result != nil {
+		fmt.Fprintln(stdout, result.text())
+	}
+	return code
+}
+
+// logFlags are the logging flags, shared by the commands which log
+type logFlags struct {
+	json      *bool
+	level     *string
+	debug     *bool
+	service   *string
+	noVersion *bool
+}
+
+func newLogFlags(fs *flag.FlagSet) *logFlags {
+	return &logFlags{
+		json:      fs.Bool("json", defaultLogJSON, "log in JSON format instead of text"),
+		level:     fs.String("loglevel", defaultLogLevel, "minimum loglevel: trace, debug, info, warn/warning, error, fatal, panic"),
+		debug:     fs.Bool("debug", defaultDebug, "shorthand for '-loglevel debug'"),
+		service:   fs.String("log-service", defaultLogServiceTag, "add a 'service=...' tag to all log messages"),
+		noVersion: fs.Bool("log-no-version", defaultDisableLogVersion, "disables adding the version to every log entry"),
+	}
+}
+
+// setup configures the package logger according to the flags, and returns whether the version is added to every
+// log entry
+func (f *logFlags) setup(fs *flag.FlagSet) (addVersionToLogs bool) {
+	log.Logger.SetOutput(os.Stdout)
+	if *f.json {
+		log.Logger.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		log.Logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+	}
+	if *f.debug {
+		*f.level = "debug"
+	}
+	if *f.level != "" {
+		lvl, err := logrus.ParseLevel(*f.level)
+		if err != nil {
+			fs.Usage()
+			log.Fatalf("invalid loglevel: %s", *f.level)
+		}
+		log.Logger.SetLevel(lvl)
+	}
+	if *f.service != "" {
+		log = log.WithField("service", *f.service)
+	}
+
+	if !*f.noVersion {
+		log = log.WithField("version", config.Version)
+	}
+	return !*f.noVersion
+}
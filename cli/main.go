@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +21,10 @@ const (
 	genesisForkVersionSepolia  = "0x90000069"
 	genesisForkVersionGoerli   = "0x00001020"
 	genesisForkVersionZhejiang = "0x00000069"
+
+	genesisTimeMainnet = 1606824023
+	genesisTimeSepolia = 1655733600
+	genesisTimeGoerli  = 1616508000
 )
 
 var (
@@ -27,41 +32,90 @@ var (
 	defaultLogJSON           = os.Getenv("LOG_JSON") != ""
 	defaultLogLevel          = getEnv("LOG_LEVEL", "info")
 	defaultListenAddr        = getEnv("BOOST_LISTEN_ADDR", "localhost:18550")
+	defaultCORSOrigins       = os.Getenv("CORS_ALLOWED_ORIGINS")
+	defaultAPIAllowedCIDRs   = os.Getenv("API_ALLOWED_CIDRS")
+	defaultAPIGzip           = os.Getenv("API_GZIP") != ""
+	defaultAPIGzipGetHeader  = os.Getenv("API_GZIP_GETHEADER") != ""
 	defaultRelayCheck        = os.Getenv("RELAY_STARTUP_CHECK") != ""
 	defaultRelayMinBidEth    = getEnvFloat64("MIN_BID_ETH", 0)
 	defaultDisableLogVersion = os.Getenv("DISABLE_LOG_VERSION") == "1" // disables adding the version to every log entry
+	defaultLogThrottleSec    = getEnvInt("LOG_THROTTLE_SEC", 60)
 	defaultDebug             = os.Getenv("DEBUG") != ""
 	defaultLogServiceTag     = os.Getenv("LOG_SERVICE_TAG")
 	defaultRelays            = os.Getenv("RELAYS")
 	defaultRelayMonitors     = os.Getenv("RELAY_MONITORS")
 	defaultMaxRetries        = getEnvInt("REQUEST_MAX_RETRIES", 5)
 
+	defaultRelayRequireScheme = os.Getenv("RELAY_REQUIRE_SCHEME") != ""
+	defaultRelayAssumeHTTPS   = os.Getenv("RELAY_ASSUME_HTTPS") != ""
+
+	defaultRelayBreakerFailures    = getEnvInt("RELAY_BREAKER_FAILURES", 0)
+	defaultRelayBreakerCooldownSec = getEnvInt("RELAY_BREAKER_COOLDOWN_SEC", 60)
+
+	defaultRegistrationMaxInFlight = getEnvInt("REGISTRATION_MAX_INFLIGHT", 0)
+
+	defaultRelayWinShareWarn = getEnvFloat64("RELAY_WIN_SHARE_WARN", 0)
+
+	defaultRelayMonitorsTopBid                  = os.Getenv("RELAY_MONITORS_TOP_BID")
+	defaultRelayMonitorRegistrationHeartbeatSec = getEnvInt("RELAY_MONITOR_REGISTRATION_HEARTBEAT_SEC", 3600)
+
+	defaultDisableGetHeader  = os.Getenv("DISABLE_GETHEADER") != ""
+	defaultDisableGetPayload = os.Getenv("DISABLE_GETPAYLOAD") != ""
+
+	defaultMaxClockSkewSec = getEnvInt("MAX_CLOCK_SKEW_SEC", 0)
+	defaultStrictClockSkew = os.Getenv("STRICT_CLOCK_SKEW") != ""
+
 	defaultGenesisForkVersion = getEnv("GENESIS_FORK_VERSION", "")
 	defaultUseSepolia         = os.Getenv("SEPOLIA") != ""
 	defaultUseGoerli          = os.Getenv("GOERLI") != ""
 	defaultUseZhejiang        = os.Getenv("ZHEJIANG") != ""
+	defaultGenesisTimestamp   = getEnvInt("GENESIS_TIMESTAMP", 0)
+
+	defaultGetHeaderSlotTolerance = getEnvInt("GETHEADER_SLOT_TOLERANCE", 1)
+	defaultStrictGetHeaderSlot    = os.Getenv("STRICT_GETHEADER_SLOT") != ""
+	defaultNoBidReasonHeader      = os.Getenv("GETHEADER_NO_BID_REASON") != ""
 
 	// mev-boost relay request timeouts (see also https://github.com/flashbots/mev-boost/issues/287)
 	defaultTimeoutMsGetHeader         = getEnvInt("RELAY_TIMEOUT_MS_GETHEADER", 950)   // timeout for getHeader requests
 	defaultTimeoutMsGetPayload        = getEnvInt("RELAY_TIMEOUT_MS_GETPAYLOAD", 4000) // timeout for getPayload requests
 	defaultTimeoutMsRegisterValidator = getEnvInt("RELAY_TIMEOUT_MS_REGVAL", 3000)     // timeout for registerValidator requests
 
-	relays        relayList
-	relayMonitors relayMonitorList
+	relays              relayList
+	relayMonitors       relayMonitorList
+	relayMonitorsTopBid relayMonitorList
+
+	relayValues        rawValues // the -relay values, parsed into relays after the flags
+	relayMonitorValues rawValues // the -relay-monitor values, parsed into relayMonitors after the flags
 
 	// cli flags
 	printVersion = flag.Bool("version", false, "only print version")
-	logJSON      = flag.Bool("json", defaultLogJSON, "log in JSON format instead of text")
-	logLevel     = flag.String("loglevel", defaultLogLevel, "minimum loglevel: trace, debug, info, warn/warning, error, fatal, panic")
-	logDebug     = flag.Bool("debug", defaultDebug, "shorthand for '-loglevel debug'")
-	logService   = flag.String("log-service", defaultLogServiceTag, "add a 'service=...' tag to all log messages")
-	logNoVersion = flag.Bool("log-no-version", defaultDisableLogVersion, "disables adding the version to every log entry")
+	logging      = newLogFlags(flag.CommandLine)
+	logThrottle  = flag.Int("log-throttle", defaultLogThrottleSec, "log identical relay and relay monitor errors at most once per period, 0 logs all [s]")
+
+	printConfigOnly = flag.Bool("print-config", false, "only print the effective configuration as JSON, with credentials redacted, exits non-zero if it is invalid")
 
 	listenAddr       = flag.String("addr", defaultListenAddr, "listen-address for mev-boost server")
+	corsOrigins      = flag.String("cors-allowed-origins", defaultCORSOrigins, "origins allowed to call the API from a browser - single entry or comma-separated list, '*' allows all")
+	apiAllowedCIDRs  = flag.String("api-allowed-cidrs", defaultAPIAllowedCIDRs, "only allow clients within these CIDRs to call the API, others get 403 - single entry or comma-separated list, empty allows all")
+	apiGzip          = flag.Bool("api-gzip", defaultAPIGzip, "compress large API responses for clients which accept gzip, for beacon nodes connecting over a slow link")
+	apiGzipGetHeader = flag.Bool("api-gzip-getheader", defaultAPIGzipGetHeader, "also compress getHeader responses, which adds latency to the auction (requires -api-gzip)")
 	relayURLs        = flag.String("relays", defaultRelays, "relay urls - single entry or comma-separated list (scheme://pubkey@host)")
 	relayCheck       = flag.Bool("relay-check", defaultRelayCheck, "check relay status on startup and on the status API call")
 	relayMinBidEth   = flag.Float64("min-bid", defaultRelayMinBidEth, "minimum bid to accept from a relay [eth]")
-	relayMonitorURLs = flag.String("relay-monitors", defaultRelayMonitors, "relay monitor urls - single entry or comma-separated list (scheme://host)")
+	relayMonitorURLs = flag.String("relay-monitors", defaultRelayMonitors, "relay monitor urls - single entry or comma-separated list (scheme://[pubkey@]host)")
+
+	relayRequireScheme = flag.Bool("relay-require-scheme", defaultRelayRequireScheme, "reject relay urls without a scheme, instead of assuming http")
+	relayAssumeHTTPS   = flag.Bool("relay-assume-https", defaultRelayAssumeHTTPS, "assume https instead of http for relay urls without a scheme")
+
+	relayBreakerFailures    = flag.Int("relay-breaker-failures", defaultRelayBreakerFailures, "skip a relay for getHeader after this many failed requests in a row, until the cooldown is over, 0 disables")
+	relayBreakerCooldownSec = flag.Int("relay-breaker-cooldown", defaultRelayBreakerCooldownSec, "how long a relay is skipped after repeated getHeader failures, before a single request probes it again [s]")
+
+	relayWinShareWarn = flag.Float64("relay-win-share-warn", defaultRelayWinShareWarn, "warn when a single relay wins more than this share of the auctions within 24h, e.g. 0.9, 0 disables the warning")
+
+	registrationMaxInFlight = flag.Int("registration-max-inflight", defaultRegistrationMaxInFlight, "validator registration batches processed at the same time, more are rejected with 503 until one is done, 0 allows any number")
+
+	relayMonitorTopBidURLs               = flag.String("relay-monitors-top-bid", defaultRelayMonitorsTopBid, "relay monitor urls which receive the top bid of every slot - single entry or comma-separated list, must also be configured as relay monitors")
+	relayMonitorRegistrationHeartbeatSec = flag.Int("relay-monitor-registration-heartbeat", defaultRelayMonitorRegistrationHeartbeatSec, "forward unchanged validator registrations to relay monitors at most once per period, 0 forwards all [s]")
 
 	relayTimeoutMsGetHeader  = flag.Int("request-timeout-getheader", defaultTimeoutMsGetHeader, "timeout for getHeader requests to the relay [ms]")
 	relayTimeoutMsGetPayload = flag.Int("request-timeout-getpayload", defaultTimeoutMsGetPayload, "timeout for getPayload requests to the relay [ms]")
@@ -69,21 +123,39 @@ var (
 
 	relayRequestMaxRetries = flag.Int("request-max-retries", defaultMaxRetries, "maximum number of retries for a relay get payload request")
 
+	disableGetHeader  = flag.Bool("disable-getheader", defaultDisableGetHeader, "respond to getHeader requests with 501, so the validator builds blocks locally (requires -disable-getpayload)")
+	disableGetPayload = flag.Bool("disable-getpayload", defaultDisableGetPayload, "respond to getPayload requests with 501")
+
+	getHeaderSlotTolerance = flag.Int("getheader-slot-tolerance", defaultGetHeaderSlotTolerance, "number of slots a getHeader request may be behind or ahead of the current slot, older requests are answered without contacting the relays")
+	strictGetHeaderSlot    = flag.Bool("strict-getheader-slot", defaultStrictGetHeaderSlot, "respond to getHeader requests for past slots with 400 instead of 204")
+	noBidReasonHeader      = flag.Bool("getheader-no-bid-reason", defaultNoBidReasonHeader, "add the reason to 204 getHeader responses, in the X-MEV-Boost-No-Bid-Reason header: no-bid, below-min, relay-error or past-slot")
+
+	maxClockSkewSec = flag.Int("max-clock-skew", defaultMaxClockSkewSec, "warn on startup and while running if the local clock differs from the relay clocks by more than this, 0 disables the check [s]")
+	strictClockSkew = flag.Bool("strict-clock-skew", defaultStrictClockSkew, "refuse to start if the local clock differs from the relay clocks by more than -max-clock-skew")
+
 	// helpers
 	useGenesisForkVersionMainnet  = flag.Bool("mainnet", true, "use Mainnet")
 	useGenesisForkVersionSepolia  = flag.Bool("sepolia", defaultUseSepolia, "use Sepolia")
 	useGenesisForkVersionGoerli   = flag.Bool("goerli", defaultUseGoerli, "use Goerli")
 	useGenesisForkVersionZhejiang = flag.Bool("zhejiang", defaultUseZhejiang, "use Zhejiang")
 	useCustomGenesisForkVersion   = flag.String("genesis-fork-version", defaultGenesisForkVersion, "use a custom genesis fork version")
+	useCustomGenesisTimestamp     = flag.Int("genesis-timestamp", defaultGenesisTimestamp, "use a custom genesis timestamp, required for the getHeader slot checks on networks without a known genesis [unix seconds]")
 )
 
 var log = logrus.NewEntry(logrus.New())
 
+func init() {
+	// process repeatable flags
+	flag.Var(&relayValues, "relay", "a single relay, can be specified multiple times, or as JSON with request headers: {\"url\": \"...\", \"headers\": {\"X-Api-Key\": \"...\"}}")
+	flag.Var(&relayMonitorValues, "relay-monitor", "a single relay monitor, can be specified multiple times")
+}
+
 // Main starts the mev-boost cli
 func Main() {
-	// process repeatable flags
-	flag.Var(&relays, "relay", "a single relay, can be specified multiple times")
-	flag.Var(&relayMonitors, "relay-monitor", "a single relay monitor, can be specified multiple times")
+	// run a subcommand, if one is given
+	if cmd, ok := findCommand(os.Args[1:]); ok {
+		os.Exit(runCommand(cmd, os.Args[2:], os.Stdout, os.Stderr))
+	}
 
 	// parse flags and get started
 	flag.Parse()
@@ -94,34 +166,13 @@ func Main() {
 		return
 	}
 
-	// setup logging
-	log.Logger.SetOutput(os.Stdout)
-	if *logJSON {
-		log.Logger.SetFormatter(&logrus.JSONFormatter{})
-	} else {
-		log.Logger.SetFormatter(&logrus.TextFormatter{
-			FullTimestamp: true,
-		})
-	}
-	if *logDebug {
-		*logLevel = "debug"
-	}
-	if *logLevel != "" {
-		lvl, err := logrus.ParseLevel(*logLevel)
-		if err != nil {
-			flag.Usage()
-			log.Fatalf("invalid loglevel: %s", *logLevel)
-		}
-		log.Logger.SetLevel(lvl)
-	}
-	if *logService != "" {
-		log = log.WithField("service", *logService)
+	// setup logging, perhaps adding the version to the logs, and say hello
+	addVersionToLogs := logging.setup(flag.CommandLine)
+	if *printConfigOnly {
+		// keep stdout for the configuration
+		log.Logger.SetOutput(os.Stderr)
 	}
-
-	// Add version to logs and say hello
-	addVersionToLogs := !*logNoVersion
 	if addVersionToLogs {
-		log = log.WithField("version", config.Version)
 		log.Infof("starting mev-boost")
 	} else {
 		log.Infof("starting mev-boost %s", config.Version)
@@ -129,49 +180,126 @@ func Main() {
 	log.Debug("debug logging enabled")
 
 	genesisForkVersionHex := ""
+	var genesisTime uint64
 	switch {
 	case *useCustomGenesisForkVersion != "":
 		genesisForkVersionHex = *useCustomGenesisForkVersion
 	case *useGenesisForkVersionSepolia:
 		genesisForkVersionHex = genesisForkVersionSepolia
+		genesisTime = genesisTimeSepolia
 	case *useGenesisForkVersionGoerli:
 		genesisForkVersionHex = genesisForkVersionGoerli
+		genesisTime = genesisTimeGoerli
 	case *useGenesisForkVersionZhejiang:
 		genesisForkVersionHex = genesisForkVersionZhejiang
 	case *useGenesisForkVersionMainnet:
 		genesisForkVersionHex = genesisForkVersionMainnet
+		genesisTime = genesisTimeMainnet
 	default:
 		flag.Usage()
 		log.Fatal("please specify a genesis fork version (eg. -mainnet / -sepolia / -goerli / -zhejiang / -genesis-fork-version flags)")
 	}
 	log.Infof("using genesis fork version: %s", genesisForkVersionHex)
 
-	// For backwards compatibility with the -relays flag.
-	if *relayURLs != "" {
-		for _, relayURL := range strings.Split(*relayURLs, ",") {
-			err := relays.Set(strings.TrimSpace(relayURL))
-			if err != nil {
-				log.WithError(err).WithField("relay", relayURL).Fatal("Invalid relay URL")
-			}
-		}
+	// The -relay and -relay-monitor values, followed by the -relays and -relay-monitors lists for backwards
+	// compatibility. The errors name the invalid value with credentials redacted.
+	if err := relays.setAll(relayValues); err != nil {
+		log.WithError(err).Fatal("Invalid relay URL")
+	}
+	if err := relays.setAll(splitList(*relayURLs)); err != nil {
+		log.WithError(err).Fatal("Invalid relay URL")
+	}
+	if *relayAssumeHTTPS {
+		relays.assumeHTTPS()
 	}
+	if err := relayMonitors.setAll(relayMonitorValues); err != nil {
+		log.WithError(err).Fatal("Invalid relay monitor URL")
+	}
+	if err := relayMonitors.setAll(splitList(*relayMonitorURLs)); err != nil {
+		log.WithError(err).Fatal("Invalid relay monitor URL")
+	}
+	if err := relayMonitorsTopBid.setAll(splitList(*relayMonitorTopBidURLs)); err != nil {
+		log.WithError(err).Fatal("Invalid relay monitor URL")
+	}
+
+	flags := flagValues{
+		relays:              relays,
+		relayRequireScheme:  *relayRequireScheme,
+		relayMonitors:       relayMonitors,
+		relayMonitorsTopBid: relayMonitorsTopBid,
+		relayMinBidEth:      *relayMinBidEth,
+
+		timeoutMsGetHeader:   *relayTimeoutMsGetHeader,
+		timeoutMsGetPayload:  *relayTimeoutMsGetPayload,
+		timeoutMsRegVal:      *relayTimeoutMsRegVal,
+		serverWriteTimeoutMs: config.ServerWriteTimeoutMs,
+		requestMaxRetries:    *relayRequestMaxRetries,
+
+		relayBreakerFailures:    *relayBreakerFailures,
+		relayBreakerCooldownSec: *relayBreakerCooldownSec,
 
-	if len(relays) == 0 {
+		registrationMaxInFlight: *registrationMaxInFlight,
+
+		relayWinShareWarn: *relayWinShareWarn,
+
+		relayMonitorRegistrationHeartbeatSec: *relayMonitorRegistrationHeartbeatSec,
+
+		disableGetHeader:  *disableGetHeader,
+		disableGetPayload: *disableGetPayload,
+
+		maxClockSkewSec: *maxClockSkewSec,
+		strictClockSkew: *strictClockSkew,
+
+		networkGenesisTime:     genesisTime,
+		genesisTimestamp:       *useCustomGenesisTimestamp,
+		getHeaderSlotTolerance: *getHeaderSlotTolerance,
+		strictGetHeaderSlot:    *strictGetHeaderSlot,
+
+		logThrottleSec: *logThrottle,
+
+		apiGzip:          *apiGzip,
+		apiGzipGetHeader: *apiGzipGetHeader,
+	}
+	if errs := flags.validate(); len(errs) > 0 {
 		flag.Usage()
-		log.Fatal("no relays specified")
+		for _, err := range errs {
+			log.Error(err.Error())
+		}
+		log.Fatalf("invalid flags (%d errors)", len(errs))
+	}
+
+	genesisTime = flags.genesisTime()
+	if genesisTime > 0 {
+		log.Infof("using genesis timestamp: %d", genesisTime)
+	} else {
+		log.Info("genesis timestamp unknown, getHeader requests are not checked against the current slot")
 	}
+
 	log.Infof("using %d relays", len(relays))
 	for index, relay := range relays {
+		if relay.ResolveAddr.IsValid() {
+			log.Infof("relay #%d: %s (resolved to %s)", index+1, relay.String(), relay.ResolveAddr.String())
+			continue
+		}
 		log.Infof("relay #%d: %s", index+1, relay.String())
 	}
-
-	// For backwards compatibility with the -relay-monitors flag.
-	if *relayMonitorURLs != "" {
-		for _, relayMonitorURL := range strings.Split(*relayMonitorURLs, ",") {
-			err := relayMonitors.Set(strings.TrimSpace(relayMonitorURL))
-			if err != nil {
-				log.WithError(err).WithField("relayMonitor", relayMonitorURL).Fatal("Invalid relay monitor URL")
+	for _, relay := range relays {
+		if relay.Weight > 0 {
+			log.WithField("relay", relay.String()).Infof("relay has weight %d, its bids win among bids of equal value", relay.Weight)
+		}
+		if len(relay.Headers) > 0 {
+			names := make([]string, 0, len(relay.Headers))
+			for name := range relay.Headers {
+				names = append(names, name)
 			}
+			sort.Strings(names)
+			log.WithField("relay", relay.String()).Infof("sending request headers to the relay: %s", strings.Join(names, ", "))
+		}
+		if len(relay.SPKIPins) > 0 {
+			log.WithField("relay", relay.String()).Infof("relay TLS certificate is pinned to %d public keys", len(relay.SPKIPins))
+		}
+		if relay.SchemeAssumed {
+			log.WithField("relay", relay.String()).Warnf("relay URL has no scheme, assuming %s - please add the scheme to the relay URL", relay.URL.Scheme)
 		}
 	}
 
@@ -181,13 +309,8 @@ func Main() {
 			log.Infof("relay-monitor #%d: %s", index+1, relayMonitor.String())
 		}
 	}
-
-	if *relayMinBidEth < 0.0 {
-		log.Fatal("Please specify a non-negative minimum bid")
-	}
-
-	if *relayMinBidEth > 1000000.0 {
-		log.Fatal("Minimum bid is too large, please ensure min-bid is denominated in Ethers")
+	if len(relayMonitorsTopBid) > 0 {
+		log.Infof("sending top bids to %d relay monitors", len(relayMonitorsTopBid))
 	}
 
 	if *relayMinBidEth > 0.0 {
@@ -199,11 +322,48 @@ func Main() {
 		log.WithError(err).Fatal("failed converting min bid")
 	}
 
+	if *relayBreakerFailures > 0 {
+		log.Infof("skipping relays for %ds after %d failed getHeader requests in a row", *relayBreakerCooldownSec, *relayBreakerFailures)
+	}
+	if *relayWinShareWarn > 0 {
+		log.Infof("warning when a single relay wins more than %.0f%% of the auctions within 24h", *relayWinShareWarn*100)
+	}
+	if *registrationMaxInFlight > 0 {
+		log.Infof("processing at most %d validator registration batches at the same time", *registrationMaxInFlight)
+	}
+
+	if *disableGetHeader {
+		log.Warn("getHeader is disabled, no bids will be served to the beacon node")
+	}
+	if *disableGetPayload {
+		log.Warn("getPayload is disabled, no payloads will be served to the beacon node")
+	}
+
+	var corsAllowedOrigins []string
+	if *corsOrigins != "" {
+		for _, origin := range strings.Split(*corsOrigins, ",") {
+			corsAllowedOrigins = append(corsAllowedOrigins, strings.TrimSpace(origin))
+		}
+		log.Infof("allowing CORS requests from: %s", strings.Join(corsAllowedOrigins, ", "))
+	}
+
+	allowedCIDRs, err := parseCIDRs(*apiAllowedCIDRs)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid API allowed CIDRs")
+	}
+	if len(allowedCIDRs) > 0 {
+		log.Infof("allowing API requests from: %v", allowedCIDRs)
+	}
+	if *apiGzip {
+		log.Info("compressing large API responses for clients which accept gzip")
+	}
+
 	opts := server.BoostServiceOpts{
 		Log:                      log,
 		ListenAddr:               *listenAddr,
 		Relays:                   relays,
 		RelayMonitors:            relayMonitors,
+		RelayMonitorsTopBid:      relayMonitorsTopBid,
 		GenesisForkVersionHex:    genesisForkVersionHex,
 		RelayCheck:               *relayCheck,
 		RelayMinBid:              *relayMinBidWei,
@@ -211,20 +371,82 @@ func Main() {
 		RequestTimeoutGetPayload: time.Duration(*relayTimeoutMsGetPayload) * time.Millisecond,
 		RequestTimeoutRegVal:     time.Duration(*relayTimeoutMsRegVal) * time.Millisecond,
 		RequestMaxRetries:        *relayRequestMaxRetries,
+
+		RelayMonitorRegistrationHeartbeat: time.Duration(*relayMonitorRegistrationHeartbeatSec) * time.Second,
+
+		DisableGetHeader:  *disableGetHeader,
+		DisableGetPayload: *disableGetPayload,
+
+		GenesisTime:            genesisTime,
+		GetHeaderSlotTolerance: uint64(*getHeaderSlotTolerance),
+		StrictGetHeaderSlot:    *strictGetHeaderSlot,
+		NoBidReasonHeader:      *noBidReasonHeader,
+
+		LogThrottleWindow: time.Duration(*logThrottle) * time.Second,
+
+		MaxClockSkew: time.Duration(*maxClockSkewSec) * time.Second,
+
+		CORSAllowedOrigins: corsAllowedOrigins,
+
+		APIAllowedCIDRs: allowedCIDRs,
+
+		RelayBreakerFailures: *relayBreakerFailures,
+		RelayBreakerCooldown: time.Duration(*relayBreakerCooldownSec) * time.Second,
+
+		APIGzip:          *apiGzip,
+		APIGzipGetHeader: *apiGzipGetHeader,
+
+		RegistrationMaxInFlight: *registrationMaxInFlight,
+
+		RelayWinShareWarn: *relayWinShareWarn,
 	}
 	service, err := server.NewBoostService(opts)
 	if err != nil {
 		log.WithError(err).Fatal("failed creating the server")
 	}
 
+	if *printConfigOnly {
+		if err := printConfig(os.Stdout, opts, *strictClockSkew); err != nil {
+			log.WithError(err).Fatal("failed printing the configuration")
+		}
+		return
+	}
+
 	if *relayCheck && service.CheckRelays() == 0 {
 		log.Error("no relay passed the health-check!")
 	}
 
+	if *maxClockSkewSec > 0 {
+		checkClockSkew(service, time.Duration(*maxClockSkewSec)*time.Second, *strictClockSkew)
+	}
+
 	log.Println("listening on", *listenAddr)
 	log.Fatal(service.StartHTTPServer())
 }
 
+// checkClockSkew compares the local clock with the relay clocks, and warns or exits if it is off by more than maxSkew
+func checkClockSkew(service *server.BoostService, maxSkew time.Duration, strict bool) {
+	skew, numSamples := service.MeasureClockSkew(2 * time.Second)
+	if numSamples == 0 {
+		log.Warn("could not check the local clock, no relay returned its time")
+		return
+	}
+
+	log := log.WithFields(logrus.Fields{
+		"skew":       skew.String(),
+		"maxSkew":    maxSkew.String(),
+		"numSamples": numSamples,
+	})
+	if skew.Abs() <= maxSkew {
+		log.Debug("local clock is in sync with the relays")
+		return
+	}
+	if strict {
+		log.Fatal("local clock differs from the relay clocks, please check the time synchronization of this machine")
+	}
+	log.Warn("local clock differs from the relay clocks, bids may be missed - please check the time synchronization of this machine")
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
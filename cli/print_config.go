@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/netip"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/flashbots/mev-boost/config"
+	"github.com/flashbots/mev-boost/server"
+)
+
+// effectiveConfig is the configuration printed by -print-config, as resolved from the flags and environment. Relay and
+// relay monitor URLs and relay request header values are redacted, they may carry credentials.
+type effectiveConfig struct {
+	Version               string `json:"version"`
+	ListenAddr            string `json:"listen_addr"`
+	GenesisForkVersionHex string `json:"genesis_fork_version"`
+	GenesisTime           uint64 `json:"genesis_time"`
+
+	Relays              []effectiveRelay `json:"relays"`
+	RelayMonitors       []string         `json:"relay_monitors"`
+	RelayMonitorsTopBid []string         `json:"relay_monitors_top_bid"`
+	RelayCheck          bool             `json:"relay_check"`
+	RelayMinBidWei      types.U256Str    `json:"min_bid_wei"`
+
+	RequestTimeoutMsGetHeader  int64 `json:"request_timeout_ms_getheader"`
+	RequestTimeoutMsGetPayload int64 `json:"request_timeout_ms_getpayload"`
+	RequestTimeoutMsRegVal     int64 `json:"request_timeout_ms_regval"`
+	RequestMaxRetries          int   `json:"request_max_retries"`
+
+	DisableGetHeader  bool `json:"disable_getheader"`
+	DisableGetPayload bool `json:"disable_getpayload"`
+
+	GetHeaderSlotTolerance uint64 `json:"getheader_slot_tolerance"`
+	StrictGetHeaderSlot    bool   `json:"strict_getheader_slot"`
+	GetHeaderNoBidReason   bool   `json:"getheader_no_bid_reason"`
+
+	MaxClockSkewSec int64 `json:"max_clock_skew_sec"`
+	StrictClockSkew bool  `json:"strict_clock_skew"`
+
+	LogThrottleSec int64 `json:"log_throttle_sec"`
+
+	RelayBreakerFailures        int   `json:"relay_breaker_failures"`
+	RelayBreakerCooldownSec     int64 `json:"relay_breaker_cooldown_sec"`
+	RelayMonitorRegHeartbeatSec int64 `json:"relay_monitor_registration_heartbeat_sec"`
+	RegistrationMaxInFlight     int   `json:"registration_max_inflight"`
+
+	RelayWinShareWarn float64 `json:"relay_win_share_warn"`
+
+	CORSAllowedOrigins []string       `json:"cors_allowed_origins"`
+	APIAllowedCIDRs    []netip.Prefix `json:"api_allowed_cidrs"`
+	APIGzip            bool           `json:"api_gzip"`
+	APIGzipGetHeader   bool           `json:"api_gzip_getheader"`
+}
+
+type effectiveRelay struct {
+	URL         string   `json:"url"`
+	ResolveAddr string   `json:"resolve_addr,omitempty"`
+	SPKIPins    []string `json:"spki_pins,omitempty"`
+	Weight      int      `json:"weight,omitempty"`
+
+	Headers map[string]string `json:"headers,omitempty"` // only the names, the values are redacted
+}
+
+// newEffectiveConfig resolves the configuration from the service options, and the options only used by the cli
+func newEffectiveConfig(opts server.BoostServiceOpts, strictClockSkew bool) effectiveConfig {
+	cfg := effectiveConfig{
+		Version:               config.Version,
+		ListenAddr:            opts.ListenAddr,
+		GenesisForkVersionHex: opts.GenesisForkVersionHex,
+		GenesisTime:           opts.GenesisTime,
+
+		Relays:              make([]effectiveRelay, len(opts.Relays)),
+		RelayMonitors:       redactRelayMonitors(opts.RelayMonitors),
+		RelayMonitorsTopBid: redactRelayMonitors(opts.RelayMonitorsTopBid),
+		RelayCheck:          opts.RelayCheck,
+		RelayMinBidWei:      opts.RelayMinBid,
+
+		RequestTimeoutMsGetHeader:  opts.RequestTimeoutGetHeader.Milliseconds(),
+		RequestTimeoutMsGetPayload: opts.RequestTimeoutGetPayload.Milliseconds(),
+		RequestTimeoutMsRegVal:     opts.RequestTimeoutRegVal.Milliseconds(),
+		RequestMaxRetries:          opts.RequestMaxRetries,
+
+		DisableGetHeader:  opts.DisableGetHeader,
+		DisableGetPayload: opts.DisableGetPayload,
+
+		GetHeaderSlotTolerance: opts.GetHeaderSlotTolerance,
+		StrictGetHeaderSlot:    opts.StrictGetHeaderSlot,
+		GetHeaderNoBidReason:   opts.NoBidReasonHeader,
+
+		MaxClockSkewSec: int64(opts.MaxClockSkew.Seconds()),
+		StrictClockSkew: strictClockSkew,
+
+		LogThrottleSec: int64(opts.LogThrottleWindow.Seconds()),
+
+		RelayBreakerFailures:        opts.RelayBreakerFailures,
+		RelayBreakerCooldownSec:     int64(opts.RelayBreakerCooldown.Seconds()),
+		RelayMonitorRegHeartbeatSec: int64(opts.RelayMonitorRegistrationHeartbeat.Seconds()),
+		RegistrationMaxInFlight:     opts.RegistrationMaxInFlight,
+
+		RelayWinShareWarn: opts.RelayWinShareWarn,
+
+		CORSAllowedOrigins: opts.CORSAllowedOrigins,
+		APIAllowedCIDRs:    opts.APIAllowedCIDRs,
+		APIGzip:            opts.APIGzip,
+		APIGzipGetHeader:   opts.APIGzipGetHeader,
+	}
+	for i, relay := range opts.Relays {
+		cfg.Relays[i] = effectiveRelay{
+			URL:      redactURL(relay.URL.String()), // the weight is printed separately
+			SPKIPins: relay.SPKIPins,
+			Weight:   relay.Weight,
+		}
+		if relay.ResolveAddr.IsValid() {
+			cfg.Relays[i].ResolveAddr = relay.ResolveAddr.String()
+		}
+		if len(relay.Headers) > 0 {
+			cfg.Relays[i].Headers = make(map[string]string, len(relay.Headers))
+			for name := range relay.Headers {
+				cfg.Relays[i].Headers[name] = "xxxxx"
+			}
+		}
+	}
+	return cfg
+}
+
+func redactRelayMonitors(relayMonitors []server.RelayMonitorEntry) []string {
+	urls := make([]string, len(relayMonitors))
+	for i, relayMonitor := range relayMonitors {
+		urls[i] = redactURL(relayMonitor.String())
+	}
+	return urls
+}
+
+// printConfig writes the effective configuration as indented JSON
+func printConfig(w io.Writer, opts server.BoostServiceOpts, strictClockSkew bool) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newEffectiveConfig(opts, strictClockSkew))
+}
@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/netip"
 	"net/url"
 	"strings"
 
@@ -10,44 +13,101 @@ import (
 
 var errDuplicateEntry = errors.New("duplicate entry")
 
+// rawValues collects the values of a repeatable flag, which are parsed after the flags. If the flag package parsed
+// them, it would print a value which failed to parse in full, including any credentials.
+type rawValues []string
+
+func (v *rawValues) String() string {
+	redacted := make([]string, len(*v))
+	for i, value := range *v {
+		redacted[i] = redactURL(value)
+	}
+	return strings.Join(redacted, ",")
+}
+
+func (v *rawValues) Set(value string) error {
+	*v = append(*v, value)
+	return nil
+}
+
+// splitList splits a comma-separated flag value into its entries
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	entries := strings.Split(value, ",")
+	for i, entry := range entries {
+		entries[i] = strings.TrimSpace(entry)
+	}
+	return entries
+}
+
 type relayList []server.RelayEntry
 
 func (r *relayList) String() string {
 	return strings.Join(server.RelayEntriesToStrings(*r), ",")
 }
 
+// Contains returns whether the list has an entry with the relay's URL, whatever its weight
 func (r *relayList) Contains(relay server.RelayEntry) bool {
 	for _, entry := range *r {
-		if relay.String() == entry.String() {
+		if relay.URL.String() == entry.URL.String() {
 			return true
 		}
 	}
 	return false
 }
 
+// Set adds the relay. Entries which differ only by weight are merged, keeping the highest weight.
 func (r *relayList) Set(value string) error {
 	relay, err := server.NewRelayEntry(value)
 	if err != nil {
 		return err
 	}
-	if r.Contains(relay) {
-		return errDuplicateEntry
+	for i, entry := range *r {
+		if relay.URL.String() != entry.URL.String() {
+			continue
+		}
+		if relay.Weight == entry.Weight {
+			return errDuplicateEntry
+		}
+		if relay.Weight > entry.Weight {
+			(*r)[i].Weight = relay.Weight
+		}
+		return nil
 	}
 	*r = append(*r, relay)
 	return nil
 }
 
-type relayMonitorList []*url.URL
+// assumeHTTPS switches the relays whose URL had no scheme from the assumed http to https
+func (r relayList) assumeHTTPS() {
+	for i := range r {
+		if r[i].SchemeAssumed {
+			u := *r[i].URL
+			u.Scheme = "https"
+			r[i].URL = &u
+		}
+	}
+}
 
-func (rm *relayMonitorList) String() string {
-	relayMonitors := []string{}
-	for _, relayMonitor := range *rm {
-		relayMonitors = append(relayMonitors, relayMonitor.String())
+// setAll adds the relays. The error names the value which failed to parse, with credentials redacted.
+func (r *relayList) setAll(values []string) error {
+	for _, value := range values {
+		if err := r.Set(value); err != nil {
+			return fmt.Errorf("%s: %w", redactURL(value), err)
+		}
 	}
-	return strings.Join(relayMonitors, ",")
+	return nil
 }
 
-func (rm *relayMonitorList) Contains(relayMonitor *url.URL) bool {
+type relayMonitorList []server.RelayMonitorEntry
+
+func (rm *relayMonitorList) String() string {
+	return strings.Join(server.RelayMonitorEntriesToStrings(*rm), ",")
+}
+
+func (rm *relayMonitorList) Contains(relayMonitor server.RelayMonitorEntry) bool {
 	for _, entry := range *rm {
 		if relayMonitor.String() == entry.String() {
 			return true
@@ -57,7 +117,7 @@ func (rm *relayMonitorList) Contains(relayMonitor *url.URL) bool {
 }
 
 func (rm *relayMonitorList) Set(value string) error {
-	relayMonitor, err := url.Parse(value)
+	relayMonitor, err := server.NewRelayMonitorEntry(value)
 	if err != nil {
 		return err
 	}
@@ -67,3 +127,60 @@ func (rm *relayMonitorList) Set(value string) error {
 	*rm = append(*rm, relayMonitor)
 	return nil
 }
+
+// setAll adds the relay monitors. The error names the value which failed to parse, with credentials redacted.
+func (rm *relayMonitorList) setAll(values []string) error {
+	for _, value := range values {
+		if err := rm.Set(value); err != nil {
+			return fmt.Errorf("%s: %w", redactURL(value), err)
+		}
+	}
+	return nil
+}
+
+// redactURL returns the URL with any password replaced, for use in log messages about URLs which failed to parse.
+// Of a relay in the JSON form, only the redacted URL is kept, the request headers may carry API keys.
+func redactURL(value string) string {
+	if strings.HasPrefix(value, "{") {
+		var relay struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal([]byte(value), &relay); err != nil || strings.HasPrefix(relay.URL, "{") {
+			return "{...}"
+		}
+		return fmt.Sprintf(`{"url": %q, ...}`, redactURL(relay.URL))
+	}
+	if u, err := url.Parse(value); err == nil && u.Opaque == "" {
+		return u.Redacted()
+	}
+	if i := strings.LastIndex(value, "@"); i >= 0 {
+		return "xxxxx@" + value[i+1:]
+	}
+	return value
+}
+
+// parseCIDRs parses a comma-separated list of CIDR prefixes. A single IP address is treated as a prefix which only
+// contains that address.
+func parseCIDRs(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %s: %w", entry, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %s: %w", entry, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
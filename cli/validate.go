@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	errNoRelays                      = errors.New("no relays specified")
+	errRelaySchemeRequired           = errors.New("relay URL has no scheme, which is required by -relay-require-scheme")
+	errNegativeMinBid                = errors.New("please specify a non-negative minimum bid")
+	errMinBidTooLarge                = errors.New("minimum bid is too large, please ensure -min-bid is denominated in Ethers")
+	errNonPositiveTimeout            = errors.New("request timeouts must be positive")
+	errWriteTimeoutBelowGetHeader    = errors.New("MEV_BOOST_SERVER_WRITE_TIMEOUT_MS must be larger than -request-timeout-getheader, or 0")
+	errNonPositiveMaxRetries         = errors.New("-request-max-retries must be positive")
+	errNegativeBreakerFailures       = errors.New("-relay-breaker-failures must not be negative")
+	errNonPositiveBreakerCooldown    = errors.New("-relay-breaker-cooldown must be positive")
+	errNegativeRegistrationHeartbeat = errors.New("-relay-monitor-registration-heartbeat must not be negative")
+	errNegativeRegistrationInFlight  = errors.New("-registration-max-inflight must not be negative")
+	errInvalidRelayWinShare          = errors.New("-relay-win-share-warn must be between 0 and 1")
+	errTopBidMonitorNotRelayMonitor  = errors.New("relay monitor receiving top bids is not configured as relay monitor")
+	errGetPayloadWithoutGetHeader    = errors.New("getPayload cannot be enabled while getHeader is disabled, please also specify -disable-getpayload")
+	errNegativeMaxClockSkew          = errors.New("-max-clock-skew must not be negative")
+	errStrictClockSkewWithoutCheck   = errors.New("-strict-clock-skew requires -max-clock-skew to be positive")
+	errNegativeGenesisTimestamp      = errors.New("please specify a non-negative genesis timestamp")
+	errNegativeSlotTolerance         = errors.New("please specify a non-negative getHeader slot tolerance")
+	errNegativeLogThrottle           = errors.New("-log-throttle must not be negative")
+	errGzipGetHeaderWithoutGzip      = errors.New("-api-gzip-getheader requires -api-gzip")
+	errStrictSlotWithoutGenesisTime  = errors.New("-strict-getheader-slot requires a known genesis time, please specify -genesis-timestamp")
+)
+
+// flagValues are the parsed flags which depend on each other. They are checked together before starting, so that
+// all invalid combinations are reported at once.
+type flagValues struct {
+	relays              relayList
+	relayRequireScheme  bool
+	relayMonitors       relayMonitorList
+	relayMonitorsTopBid relayMonitorList
+	relayMinBidEth      float64
+
+	timeoutMsGetHeader   int
+	timeoutMsGetPayload  int
+	timeoutMsRegVal      int
+	serverWriteTimeoutMs int // the server write timeout from MEV_BOOST_SERVER_WRITE_TIMEOUT_MS, 0 disables it
+	requestMaxRetries    int
+
+	relayBreakerFailures    int
+	relayBreakerCooldownSec int
+
+	registrationMaxInFlight int
+
+	relayWinShareWarn float64
+
+	relayMonitorRegistrationHeartbeatSec int
+
+	disableGetHeader  bool
+	disableGetPayload bool
+
+	maxClockSkewSec int
+	strictClockSkew bool
+
+	networkGenesisTime     uint64 // genesis time of the selected network, 0 if unknown
+	genesisTimestamp       int    // custom genesis timestamp
+	getHeaderSlotTolerance int
+	strictGetHeaderSlot    bool
+
+	logThrottleSec int
+
+	apiGzip          bool
+	apiGzipGetHeader bool
+}
+
+// validate returns all violated rules. It does not stop at the first one.
+func (f *flagValues) validate() []error {
+	var errs []error
+	if len(f.relays) == 0 {
+		errs = append(errs, errNoRelays)
+	}
+	if f.relayRequireScheme {
+		for _, relay := range f.relays {
+			if relay.SchemeAssumed {
+				corrected := *relay.URL
+				corrected.Scheme = "https"
+				errs = append(errs, fmt.Errorf("%w, did you mean %s?", errRelaySchemeRequired, corrected.String()))
+			}
+		}
+	}
+	if f.relayMinBidEth < 0.0 {
+		errs = append(errs, errNegativeMinBid)
+	}
+	if f.relayMinBidEth > 1000000.0 {
+		errs = append(errs, errMinBidTooLarge)
+	}
+	if f.timeoutMsGetHeader <= 0 || f.timeoutMsGetPayload <= 0 || f.timeoutMsRegVal <= 0 {
+		errs = append(errs, errNonPositiveTimeout)
+	}
+	// The getHeader response is written after the relays responded, a shorter write timeout would cut it off
+	if f.serverWriteTimeoutMs > 0 && f.serverWriteTimeoutMs <= f.timeoutMsGetHeader {
+		errs = append(errs, errWriteTimeoutBelowGetHeader)
+	}
+	if f.requestMaxRetries <= 0 {
+		errs = append(errs, errNonPositiveMaxRetries)
+	}
+	if f.relayBreakerFailures < 0 {
+		errs = append(errs, errNegativeBreakerFailures)
+	}
+	if f.relayBreakerFailures > 0 && f.relayBreakerCooldownSec <= 0 {
+		errs = append(errs, errNonPositiveBreakerCooldown)
+	}
+	if f.relayWinShareWarn < 0 || f.relayWinShareWarn > 1 {
+		errs = append(errs, errInvalidRelayWinShare)
+	}
+	if f.registrationMaxInFlight < 0 {
+		errs = append(errs, errNegativeRegistrationInFlight)
+	}
+	if f.relayMonitorRegistrationHeartbeatSec < 0 {
+		errs = append(errs, errNegativeRegistrationHeartbeat)
+	}
+	for _, relayMonitor := range f.relayMonitorsTopBid {
+		if !f.relayMonitors.Contains(relayMonitor) {
+			errs = append(errs, fmt.Errorf("%w: %s", errTopBidMonitorNotRelayMonitor, relayMonitor.String()))
+		}
+	}
+	if f.disableGetHeader && !f.disableGetPayload {
+		errs = append(errs, errGetPayloadWithoutGetHeader)
+	}
+	if f.maxClockSkewSec < 0 {
+		errs = append(errs, errNegativeMaxClockSkew)
+	}
+	if f.strictClockSkew && f.maxClockSkewSec <= 0 {
+		errs = append(errs, errStrictClockSkewWithoutCheck)
+	}
+	if f.genesisTimestamp < 0 {
+		errs = append(errs, errNegativeGenesisTimestamp)
+	}
+	if f.getHeaderSlotTolerance < 0 {
+		errs = append(errs, errNegativeSlotTolerance)
+	}
+	if f.strictGetHeaderSlot && f.genesisTime() == 0 {
+		errs = append(errs, errStrictSlotWithoutGenesisTime)
+	}
+	if f.logThrottleSec < 0 {
+		errs = append(errs, errNegativeLogThrottle)
+	}
+	if f.apiGzipGetHeader && !f.apiGzip {
+		errs = append(errs, errGzipGetHeaderWithoutGzip)
+	}
+	return errs
+}
+
+// genesisTime returns the custom genesis timestamp if set, and the genesis time of the selected network otherwise
+func (f *flagValues) genesisTime() uint64 {
+	if f.genesisTimestamp > 0 {
+		return uint64(f.genesisTimestamp)
+	}
+	return f.networkGenesisTime
+}
@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/flashbots/mev-boost/server"
+	"github.com/stretchr/testify/require"
+)
+
+const testRelayPubkey = "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+
+func mustRelayEntry(t *testing.T, relayURL string) server.RelayEntry {
+	t.Helper()
+	relay, err := server.NewRelayEntry(relayURL)
+	require.NoError(t, err)
+	return relay
+}
+
+func validFlagValues(t *testing.T) flagValues {
+	t.Helper()
+	return flagValues{
+		relays:                               relayList{mustRelayEntry(t, "https://"+testRelayPubkey+"@relay.example.com")},
+		timeoutMsGetHeader:                   950,
+		timeoutMsGetPayload:                  4000,
+		timeoutMsRegVal:                      3000,
+		requestMaxRetries:                    5,
+		relayBreakerCooldownSec:              60,
+		relayMonitorRegistrationHeartbeatSec: 3600,
+		maxClockSkewSec:                      5,
+		networkGenesisTime:                   genesisTimeMainnet,
+		getHeaderSlotTolerance:               1,
+	}
+}
+
+func TestValidateFlags(t *testing.T) {
+	relayMonitor, err := server.NewRelayMonitorEntry("https://relay-monitor.example.com")
+	require.NoError(t, err)
+	otherRelayMonitor, err := server.NewRelayMonitorEntry("https://relay-monitor2.example.com")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		modify   func(f *flagValues)
+		expected []error
+	}{
+		{
+			name:   "defaults",
+			modify: func(f *flagValues) {},
+		},
+		{
+			name:     "no relays",
+			modify:   func(f *flagValues) { f.relays = nil },
+			expected: []error{errNoRelays},
+		},
+		{
+			name:   "relay without scheme",
+			modify: func(f *flagValues) { f.relays = relayList{mustRelayEntry(t, testRelayPubkey+"@relay.example.com")} },
+		},
+		{
+			name: "relay without scheme when a scheme is required",
+			modify: func(f *flagValues) {
+				f.relays = relayList{mustRelayEntry(t, testRelayPubkey+"@relay.example.com")}
+				f.relayRequireScheme = true
+			},
+			expected: []error{errRelaySchemeRequired},
+		},
+		{
+			name:   "relay with scheme when a scheme is required",
+			modify: func(f *flagValues) { f.relayRequireScheme = true },
+		},
+		{
+			name:     "negative min bid",
+			modify:   func(f *flagValues) { f.relayMinBidEth = -1 },
+			expected: []error{errNegativeMinBid},
+		},
+		{
+			name:     "min bid too large",
+			modify:   func(f *flagValues) { f.relayMinBidEth = 1000001 },
+			expected: []error{errMinBidTooLarge},
+		},
+		{
+			name:     "zero timeout",
+			modify:   func(f *flagValues) { f.timeoutMsGetPayload = 0 },
+			expected: []error{errNonPositiveTimeout},
+		},
+		{
+			name:     "zero max retries",
+			modify:   func(f *flagValues) { f.requestMaxRetries = 0 },
+			expected: []error{errNonPositiveMaxRetries},
+		},
+		{
+			name:     "negative registration heartbeat",
+			modify:   func(f *flagValues) { f.relayMonitorRegistrationHeartbeatSec = -1 },
+			expected: []error{errNegativeRegistrationHeartbeat},
+		},
+		{
+			name: "top bid monitor is a relay monitor",
+			modify: func(f *flagValues) {
+				f.relayMonitors = relayMonitorList{relayMonitor, otherRelayMonitor}
+				f.relayMonitorsTopBid = relayMonitorList{relayMonitor}
+			},
+		},
+		{
+			name: "top bid monitor is not a relay monitor",
+			modify: func(f *flagValues) {
+				f.relayMonitors = relayMonitorList{relayMonitor}
+				f.relayMonitorsTopBid = relayMonitorList{otherRelayMonitor}
+			},
+			expected: []error{errTopBidMonitorNotRelayMonitor},
+		},
+		{
+			name:     "getHeader disabled without getPayload",
+			modify:   func(f *flagValues) { f.disableGetHeader = true },
+			expected: []error{errGetPayloadWithoutGetHeader},
+		},
+		{
+			name: "getHeader and getPayload disabled",
+			modify: func(f *flagValues) {
+				f.disableGetHeader = true
+				f.disableGetPayload = true
+			},
+		},
+		{
+			name:     "negative relay breaker failures",
+			modify:   func(f *flagValues) { f.relayBreakerFailures = -1 },
+			expected: []error{errNegativeBreakerFailures},
+		},
+		{
+			name: "relay breaker without cooldown",
+			modify: func(f *flagValues) {
+				f.relayBreakerFailures = 3
+				f.relayBreakerCooldownSec = 0
+			},
+			expected: []error{errNonPositiveBreakerCooldown},
+		},
+		{
+			name:     "write timeout below getHeader timeout",
+			modify:   func(f *flagValues) { f.serverWriteTimeoutMs = f.timeoutMsGetHeader },
+			expected: []error{errWriteTimeoutBelowGetHeader},
+		},
+		{
+			name:     "write timeout above getHeader timeout",
+			modify:   func(f *flagValues) { f.serverWriteTimeoutMs = f.timeoutMsGetHeader + 1000 },
+			expected: nil,
+		},
+		{
+			name:     "negative registration batches in flight",
+			modify:   func(f *flagValues) { f.registrationMaxInFlight = -1 },
+			expected: []error{errNegativeRegistrationInFlight},
+		},
+		{
+			name:     "relay win share above 1",
+			modify:   func(f *flagValues) { f.relayWinShareWarn = 90 },
+			expected: []error{errInvalidRelayWinShare},
+		},
+		{
+			name:     "negative max clock skew",
+			modify:   func(f *flagValues) { f.maxClockSkewSec = -1 },
+			expected: []error{errNegativeMaxClockSkew},
+		},
+		{
+			name: "strict clock skew without check",
+			modify: func(f *flagValues) {
+				f.maxClockSkewSec = 0
+				f.strictClockSkew = true
+			},
+			expected: []error{errStrictClockSkewWithoutCheck},
+		},
+		{
+			name:     "negative genesis timestamp",
+			modify:   func(f *flagValues) { f.genesisTimestamp = -1 },
+			expected: []error{errNegativeGenesisTimestamp},
+		},
+		{
+			name:     "negative slot tolerance",
+			modify:   func(f *flagValues) { f.getHeaderSlotTolerance = -1 },
+			expected: []error{errNegativeSlotTolerance},
+		},
+		{
+			name: "strict getHeader slot without genesis time",
+			modify: func(f *flagValues) {
+				f.networkGenesisTime = 0
+				f.strictGetHeaderSlot = true
+			},
+			expected: []error{errStrictSlotWithoutGenesisTime},
+		},
+		{
+			name:     "negative log throttle",
+			modify:   func(f *flagValues) { f.logThrottleSec = -1 },
+			expected: []error{errNegativeLogThrottle},
+		},
+		{
+			name:     "getHeader compression without compression",
+			modify:   func(f *flagValues) { f.apiGzipGetHeader = true },
+			expected: []error{errGzipGetHeaderWithoutGzip},
+		},
+		{
+			name: "strict getHeader slot with custom genesis time",
+			modify: func(f *flagValues) {
+				f.networkGenesisTime = 0
+				f.genesisTimestamp = 1675263600
+				f.strictGetHeaderSlot = true
+			},
+		},
+		{
+			name: "all violations are reported",
+			modify: func(f *flagValues) {
+				f.relays = nil
+				f.relayMinBidEth = -1
+				f.disableGetHeader = true
+			},
+			expected: []error{errNoRelays, errNegativeMinBid, errGetPayloadWithoutGetHeader},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := validFlagValues(t)
+			tt.modify(&f)
+			errs := f.validate()
+			require.Len(t, errs, len(tt.expected), errs)
+			for i, err := range errs {
+				require.ErrorIs(t, err, tt.expected[i])
+			}
+		})
+	}
+}
+
+func TestFlagValuesGenesisTime(t *testing.T) {
+	f := flagValues{networkGenesisTime: genesisTimeMainnet}
+	require.Equal(t, uint64(genesisTimeMainnet), f.genesisTime())
+
+	f.genesisTimestamp = 1675263600
+	require.Equal(t, uint64(1675263600), f.genesisTime())
+}
+
+func TestRelaySchemeRequiredErrorShowsCorrectedURL(t *testing.T) {
+	f := validFlagValues(t)
+	f.relays = relayList{mustRelayEntry(t, testRelayPubkey+"@relay.example.com")}
+	f.relayRequireScheme = true
+	errs := f.validate()
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "did you mean https://"+testRelayPubkey+"@relay.example.com?")
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Outcomes of an auction, as reported in the auction summary
+const (
+	auctionOutcomeWon      = "won"       // a bid was returned to the proposer
+	auctionOutcomeNoBid    = "no-bid"    // no relay had a bid, or the slot was not auctioned
+	auctionOutcomeBelowMin = "below-min" // bids were received, but none of them met the minimum bid
+	auctionOutcomeError    = "error"     // the request was rejected, or none of the relays responded successfully
+)
+
+// headerNoBidReason carries the reason of a 204 getHeader response, if enabled
+const headerNoBidReason = "X-MEV-Boost-No-Bid-Reason"
+
+// Reasons of a 204 getHeader response, as sent in headerNoBidReason
+const (
+	noBidReasonNoBid      = "no-bid"      // the relays responded, but none of them had a bid
+	noBidReasonBelowMin   = "below-min"   // bids were received, but none of them met the minimum bid
+	noBidReasonRelayError = "relay-error" // none of the relays responded successfully
+	noBidReasonPastSlot   = "past-slot"   // the slot is in the past, the relays were not asked
+)
+
+// Results of a getPayload request, as reported in the payload summary
+const (
+	payloadResultDelivered = "delivered"
+	payloadResultFailed    = "failed"
+)
+
+// auctionSummary is logged exactly once per getHeader request, in a stable format which can be ingested by tooling.
+// Fields which are unknown for the outcome, like the best value if there was no bid, are logged as empty strings.
+type auctionSummary struct {
+	start         time.Time
+	slot          string
+	parentHash    string
+	pubkey        string
+	relaysQueried int
+	bidsReceived  int
+	bestValue     *big.Int
+	chosenRelays  []RelayEntry
+	outcome       string
+	pastSlot      bool // the slot was in the past, so the relays were not asked
+}
+
+func newAuctionSummary(slot, parentHash, pubkey string) *auctionSummary {
+	return &auctionSummary{
+		start:      time.Now(),
+		slot:       slot,
+		parentHash: parentHash,
+		pubkey:     pubkey,
+		outcome:    auctionOutcomeError,
+	}
+}
+
+// setRelayOutcomes derives the outcome of the auction from how the relays responded
+func (s *auctionSummary) setRelayOutcomes(outcomes getHeaderOutcomes, bestValue *big.Int, won bool) {
+	s.bidsReceived = outcomes[getHeaderOutcomeBid] + outcomes[getHeaderOutcomeBelowMin]
+	s.bestValue = bestValue
+	switch {
+	case won:
+		s.outcome = auctionOutcomeWon
+	case outcomes[getHeaderOutcomeBelowMin] > 0:
+		s.outcome = auctionOutcomeBelowMin
+	case outcomes[getHeaderOutcomeNoBid] > 0 || outcomes[getHeaderOutcomeRejected] > 0:
+		s.outcome = auctionOutcomeNoBid
+	default:
+		s.outcome = auctionOutcomeError
+	}
+}
+
+// noBidReason returns the reason of a 204 response. It is derived from the outcome, so that the header and the auction
+// summary always agree.
+func (s *auctionSummary) noBidReason() string {
+	switch {
+	case s.pastSlot:
+		return noBidReasonPastSlot
+	case s.outcome == auctionOutcomeBelowMin:
+		return noBidReasonBelowMin
+	case s.outcome == auctionOutcomeNoBid:
+		return noBidReasonNoBid
+	default:
+		return noBidReasonRelayError
+	}
+}
+
+func (s *auctionSummary) logFields() logrus.Fields {
+	bestValue := ""
+	if s.bestValue != nil {
+		bestValue = s.bestValue.String()
+	}
+	return logrus.Fields{
+		"method":        "getHeader",
+		"slot":          s.slot,
+		"parentHash":    s.parentHash,
+		"pubkey":        s.pubkey,
+		"relaysQueried": s.relaysQueried,
+		"bidsReceived":  s.bidsReceived,
+		"bestValue":     bestValue,
+		"chosenRelay":   strings.Join(RelayEntriesToStrings(s.chosenRelays), ", "),
+		"latencyMs":     time.Since(s.start).Milliseconds(),
+		"outcome":       s.outcome,
+	}
+}
+
+// payloadSummary is logged exactly once per getPayload request which is sent to the relays. It shares the correlation
+// fields slot, parentHash and pubkey with the auction summary of the bid.
+type payloadSummary struct {
+	start         time.Time
+	slot          string
+	parentHash    string
+	blockHash     string
+	pubkey        string // the proposer, if the bid is known
+	relaysQueried int
+	deliveredBy   *RelayEntry
+	result        string
+}
+
+func (s *payloadSummary) logFields() logrus.Fields {
+	relay := ""
+	if s.deliveredBy != nil {
+		relay = s.deliveredBy.String()
+	}
+	return logrus.Fields{
+		"method":        "getPayload",
+		"slot":          s.slot,
+		"parentHash":    s.parentHash,
+		"blockHash":     s.blockHash,
+		"pubkey":        s.pubkey,
+		"relaysQueried": s.relaysQueried,
+		"chosenRelay":   relay,
+		"latencyMs":     time.Since(s.start).Milliseconds(),
+		"payloadResult": s.result,
+	}
+}
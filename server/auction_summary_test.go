@@ -0,0 +1,244 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	consensusspec "github.com/attestantio/go-eth2-client/spec"
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+// logEntriesWithMessage returns the log entries with the given message
+func logEntriesWithMessage(hook *logrustest.Hook, msg string) []logrus.Entry {
+	var entries []logrus.Entry
+	for _, entry := range hook.AllEntries() {
+		if entry.Message == msg {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
+
+func requireSummaryFields(t *testing.T, entry logrus.Entry, fields ...string) {
+	t.Helper()
+	require.Equal(t, logrus.InfoLevel, entry.Level)
+	require.Len(t, entry.Data, len(fields), "unexpected fields: %v", entry.Data)
+	for _, field := range fields {
+		require.Contains(t, entry.Data, field)
+	}
+}
+
+func TestAuctionSummary(t *testing.T) {
+	hash := _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7")
+	pubkey := _HexToPubkey(
+		"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+	path := getHeaderPath(1, hash, pubkey)
+
+	summaryFields := []string{"method", "slot", "parentHash", "pubkey", "relaysQueried", "bidsReceived", "bestValue", "chosenRelay", "latencyMs", "outcome"}
+
+	noBid := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	relayError := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+
+	testCases := []struct {
+		name             string
+		path             string
+		setup            func(backend *testBackend)
+		expectedOutcome  string
+		expectedBids     int
+		expectedBest     string
+		expectChosen     bool
+		expectedQueried  int
+		expectedReason   string
+		expectedHTTPCode int
+	}{
+		{
+			name:             "won",
+			path:             path,
+			expectedOutcome:  auctionOutcomeWon,
+			expectedBids:     2,
+			expectedBest:     "12345",
+			expectChosen:     true,
+			expectedQueried:  2,
+			expectedHTTPCode: http.StatusOK,
+		},
+		{
+			name: "no-bid",
+			path: path,
+			setup: func(backend *testBackend) {
+				backend.relays[0].handlerOverrideGetHeader = noBid
+				backend.relays[1].handlerOverrideGetHeader = noBid
+			},
+			expectedOutcome:  auctionOutcomeNoBid,
+			expectedQueried:  2,
+			expectedReason:   noBidReasonNoBid,
+			expectedHTTPCode: http.StatusNoContent,
+		},
+		{
+			name: "below-min",
+			path: path,
+			setup: func(backend *testBackend) {
+				backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(
+					12344,
+					"0xa28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+					"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+					"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+					consensusspec.DataVersionBellatrix,
+				)
+				backend.relays[1].handlerOverrideGetHeader = noBid
+			},
+			expectedOutcome:  auctionOutcomeBelowMin,
+			expectedBids:     1,
+			expectedBest:     "12344",
+			expectedQueried:  2,
+			expectedReason:   noBidReasonBelowMin,
+			expectedHTTPCode: http.StatusNoContent,
+		},
+		{
+			name: "error",
+			path: path,
+			setup: func(backend *testBackend) {
+				backend.relays[0].handlerOverrideGetHeader = relayError
+				backend.relays[1].handlerOverrideGetHeader = relayError
+			},
+			expectedOutcome:  auctionOutcomeError,
+			expectedQueried:  2,
+			expectedReason:   noBidReasonRelayError,
+			expectedHTTPCode: http.StatusNoContent,
+		},
+		{
+			name:             "error on invalid request",
+			path:             "/eth/v1/builder/header/1/0x1/" + pubkey.String(),
+			expectedOutcome:  auctionOutcomeError,
+			expectedHTTPCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := newTestBackend(t, 2, time.Second)
+			logger, hook := logrustest.NewNullLogger()
+			backend.boost.log = logrus.NewEntry(logger)
+			backend.boost.noBidReasonHeader = true
+			if tc.setup != nil {
+				tc.setup(backend)
+			}
+
+			rr := backend.request(t, http.MethodGet, tc.path, nil)
+			require.Equal(t, tc.expectedHTTPCode, rr.Code, rr.Body.String())
+			require.Equal(t, tc.expectedReason, rr.Header().Get(headerNoBidReason))
+
+			entries := logEntriesWithMessage(hook, "auction summary")
+			require.Len(t, entries, 1)
+			entry := entries[0]
+			requireSummaryFields(t, entry, summaryFields...)
+			require.Equal(t, "getHeader", entry.Data["method"])
+			require.Equal(t, "1", entry.Data["slot"])
+			require.Equal(t, pubkey.String(), entry.Data["pubkey"])
+			require.Equal(t, tc.expectedOutcome, entry.Data["outcome"])
+			require.Equal(t, tc.expectedQueried, entry.Data["relaysQueried"])
+			require.Equal(t, tc.expectedBids, entry.Data["bidsReceived"])
+			require.Equal(t, tc.expectedBest, entry.Data["bestValue"])
+			if tc.expectChosen {
+				require.NotEmpty(t, entry.Data["chosenRelay"])
+			} else {
+				require.Equal(t, "", entry.Data["chosenRelay"])
+			}
+		})
+	}
+}
+
+func TestNoBidReasonHeader(t *testing.T) {
+	hash := _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7")
+	pubkey := _HexToPubkey(
+		"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+
+	t.Run("past slot", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.noBidReasonHeader = true
+		// Genesis in the middle of a slot, so that the current slot is 100
+		backend.boost.slotClock = slotClock{genesisTime: uint64(time.Now().Unix()) - 100*secondsPerSlot - 6}
+
+		rr := backend.request(t, http.MethodGet, getHeaderPath(98, hash, pubkey), nil)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Equal(t, noBidReasonPastSlot, rr.Header().Get(headerNoBidReason))
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.relays[0].handlerOverrideGetHeader = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		rr := backend.request(t, http.MethodGet, getHeaderPath(1, hash, pubkey), nil)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Empty(t, rr.Header().Values(headerNoBidReason))
+	})
+}
+
+func TestPayloadSummary(t *testing.T) {
+	path := "/eth/v1/builder/blinded_blocks"
+	payload := types.SignedBlindedBeaconBlock{
+		Signature: _HexToSignature(
+			"0x8c795f751f812eabbabdee85100a06730a9904a4b53eedaa7f546fe0e23cd75125e293c6b0d007aa68a9da4441929d16072668abb4323bb04ac81862907357e09271fe414147b3669509d91d8ffae2ec9c789a5fcd4519629b8f2c7de8d0cce9"),
+		Message: &types.BlindedBeaconBlock{
+			Slot:          1,
+			ProposerIndex: 1,
+			ParentRoot:    types.Root{0x01},
+			StateRoot:     types.Root{0x02},
+			Body: &types.BlindedBeaconBlockBody{
+				RandaoReveal:  types.Signature{0xa1},
+				Eth1Data:      &types.Eth1Data{},
+				Graffiti:      types.Hash{0xa2},
+				SyncAggregate: &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{
+					ParentHash:   _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7"),
+					BlockHash:    _HexToHash("0x534809bd2b6832edff8d8ce4cb0e50068804fd1ef432c8362ad708a74fdc0e46"),
+					BlockNumber:  12345,
+					FeeRecipient: _HexToAddress("0xdb65fEd33dc262Fe09D9a2Ba8F80b329BA25f941"),
+				},
+			},
+		},
+	}
+
+	summaryFields := []string{"method", "slot", "parentHash", "blockHash", "pubkey", "relaysQueried", "chosenRelay", "latencyMs", "payloadResult"}
+
+	for _, delivered := range []bool{true, false} {
+		expectedResult := payloadResultFailed
+		if delivered {
+			expectedResult = payloadResultDelivered
+		}
+		t.Run(expectedResult, func(t *testing.T) {
+			backend := newTestBackend(t, 1, time.Second)
+			logger, hook := logrustest.NewNullLogger()
+			backend.boost.log = logrus.NewEntry(logger)
+			if !delivered {
+				backend.relays[0].GetBellatrixPayloadResponse = new(types.GetPayloadResponse)
+			}
+
+			backend.request(t, http.MethodPost, path, payload)
+
+			entries := logEntriesWithMessage(hook, "payload summary")
+			require.Len(t, entries, 1)
+			entry := entries[0]
+			requireSummaryFields(t, entry, summaryFields...)
+			require.Equal(t, "getPayload", entry.Data["method"])
+			require.Equal(t, "1", entry.Data["slot"])
+			require.Equal(t, payload.Message.Body.ExecutionPayloadHeader.ParentHash.String(), entry.Data["parentHash"])
+			require.Equal(t, 1, entry.Data["relaysQueried"])
+			require.Equal(t, expectedResult, entry.Data["payloadResult"])
+			if delivered {
+				require.Equal(t, backend.relays[0].RelayEntry.String(), entry.Data["chosenRelay"])
+			} else {
+				require.Equal(t, "", entry.Data["chosenRelay"])
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	// bidCacheKeepSlots is the number of slots before the latest one for which bids are kept
+	bidCacheKeepSlots = 64
+
+	// bidCacheMaxEntries is the maximum number of bids kept, older slots are evicted least-recently-used first
+	bidCacheMaxEntries = 10_000
+)
+
+type bidCacheEntry struct {
+	key bidRespKey
+	bid bidResp
+}
+
+// bidCache keeps track of the bids served by getHeader, to be matched by getPayload. Entries are evicted when they
+// are more than keepSlots behind the latest slot, and least-recently-used once maxEntries is reached. The entries of
+// the latest slot are never evicted by the entry limit.
+type bidCache struct {
+	keepSlots  uint64
+	maxEntries int
+
+	// maxSlot, if set, returns the highest slot which can become the latest slot. Otherwise a single request for a
+	// bogus far future slot would stop the pruning by slot for good.
+	maxSlot func() uint64
+
+	mu         sync.Mutex
+	entries    map[bidRespKey]*list.Element
+	lru        *list.List // front is most recently used
+	latestSlot uint64
+}
+
+func newBidCache(keepSlots uint64, maxEntries int) *bidCache {
+	return &bidCache{
+		keepSlots:  keepSlots,
+		maxEntries: maxEntries,
+		entries:    make(map[bidRespKey]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// add stores a bid, and returns the number of entries which had to be evicted because of the entry limit
+func (c *bidCache) add(key bidRespKey, bid bidResp) (numEvicted int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	slot := key.slot
+	if c.maxSlot != nil && slot > c.maxSlot() {
+		slot = c.maxSlot()
+	}
+	if slot > c.latestSlot {
+		c.latestSlot = slot
+		c.pruneSlots()
+	}
+
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*bidCacheEntry).bid = bid //nolint:forcetypeassert
+		c.lru.MoveToFront(el)
+		return 0
+	}
+	c.entries[key] = c.lru.PushFront(&bidCacheEntry{key: key, bid: bid})
+
+	// Evict the least recently used entries, skipping the ones of the latest slot
+	el := c.lru.Back()
+	for len(c.entries) > c.maxEntries && el != nil {
+		prev := el.Prev()
+		entry := el.Value.(*bidCacheEntry) //nolint:forcetypeassert
+		if entry.key.slot != c.latestSlot {
+			c.remove(el)
+			numEvicted++
+		}
+		el = prev
+	}
+	return numEvicted
+}
+
+// get returns the bid for the given key, if any
+func (c *bidCache) get(key bidRespKey) (bidResp, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return bidResp{}, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*bidCacheEntry).bid, true //nolint:forcetypeassert
+}
+
+// pruneOlderThan removes all bids received longer than maxAge ago
+func (c *bidCache) pruneOlderThan(maxAge time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, el := range c.entries {
+		if time.Since(el.Value.(*bidCacheEntry).bid.t) > maxAge { //nolint:forcetypeassert
+			c.remove(el)
+		}
+	}
+}
+
+// len returns the number of bids currently kept
+func (c *bidCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// pruneSlots removes all bids for slots too far behind the latest slot. Must be called with the lock held.
+func (c *bidCache) pruneSlots() {
+	if c.latestSlot <= c.keepSlots {
+		return
+	}
+	minSlot := c.latestSlot - c.keepSlots
+	for key, el := range c.entries {
+		if key.slot < minSlot {
+			c.remove(el)
+		}
+	}
+}
+
+// remove deletes a single entry. Must be called with the lock held.
+func (c *bidCache) remove(el *list.Element) {
+	delete(c.entries, el.Value.(*bidCacheEntry).key) //nolint:forcetypeassert
+	c.lru.Remove(el)
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testBidRespKey(slot uint64, i int) bidRespKey {
+	return bidRespKey{slot: slot, blockHash: fmt.Sprintf("0x%064x", i)}
+}
+
+func TestBidCache(t *testing.T) {
+	t.Run("stores and returns bids", func(t *testing.T) {
+		c := newBidCache(2, 10)
+		key := testBidRespKey(1, 1)
+		c.add(key, bidResp{blockHash: key.blockHash})
+
+		bid, ok := c.get(key)
+		require.True(t, ok)
+		require.Equal(t, key.blockHash, bid.blockHash)
+
+		_, ok = c.get(testBidRespKey(1, 2))
+		require.False(t, ok)
+	})
+
+	t.Run("memory stays bounded without getPayload calls", func(t *testing.T) {
+		c := newBidCache(bidCacheKeepSlots, bidCacheMaxEntries)
+		for slot := uint64(1); slot <= 5000; slot++ {
+			for i := 0; i < 3; i++ {
+				require.Equal(t, 0, c.add(testBidRespKey(slot, i), bidResp{t: time.Now()}))
+			}
+			require.LessOrEqual(t, c.len(), (bidCacheKeepSlots+1)*3)
+		}
+		require.Equal(t, (bidCacheKeepSlots+1)*3, c.len())
+		require.Equal(t, c.len(), c.lru.Len())
+
+		_, ok := c.get(testBidRespKey(5000-bidCacheKeepSlots-1, 0))
+		require.False(t, ok)
+		_, ok = c.get(testBidRespKey(5000-bidCacheKeepSlots, 0))
+		require.True(t, ok)
+	})
+
+	t.Run("evicts least recently used entries of previous slots", func(t *testing.T) {
+		c := newBidCache(100, 4)
+		c.add(testBidRespKey(1, 1), bidResp{})
+		c.add(testBidRespKey(1, 2), bidResp{})
+		c.add(testBidRespKey(1, 3), bidResp{})
+
+		// Use the first entry, so that the second one is the least recently used
+		_, ok := c.get(testBidRespKey(1, 1))
+		require.True(t, ok)
+
+		c.add(testBidRespKey(2, 1), bidResp{})
+		require.Equal(t, 1, c.add(testBidRespKey(2, 2), bidResp{}))
+		require.Equal(t, 4, c.len())
+
+		_, ok = c.get(testBidRespKey(1, 2))
+		require.False(t, ok)
+		_, ok = c.get(testBidRespKey(1, 1))
+		require.True(t, ok)
+	})
+
+	t.Run("never evicts entries of the current slot", func(t *testing.T) {
+		c := newBidCache(100, 4)
+		c.add(testBidRespKey(1, 0), bidResp{})
+		for i := 0; i < 10; i++ {
+			c.add(testBidRespKey(2, i), bidResp{})
+		}
+		require.Equal(t, 10, c.len())
+		for i := 0; i < 10; i++ {
+			_, ok := c.get(testBidRespKey(2, i))
+			require.True(t, ok)
+		}
+	})
+
+	t.Run("far future slots do not stop the pruning", func(t *testing.T) {
+		c := newBidCache(2, 10)
+		currentSlot := uint64(10)
+		c.maxSlot = func() uint64 { return currentSlot + 1 }
+
+		c.add(testBidRespKey(1_000_000, 0), bidResp{})
+		require.Equal(t, uint64(11), c.latestSlot)
+
+		for ; currentSlot < 20; currentSlot++ {
+			c.add(testBidRespKey(currentSlot, 0), bidResp{})
+		}
+		_, ok := c.get(testBidRespKey(15, 0))
+		require.False(t, ok)
+		_, ok = c.get(testBidRespKey(19, 0))
+		require.True(t, ok)
+	})
+
+	t.Run("prunes entries by age", func(t *testing.T) {
+		c := newBidCache(100, 100)
+		c.add(testBidRespKey(1, 1), bidResp{t: time.Now().Add(-time.Hour)})
+		c.add(testBidRespKey(1, 2), bidResp{t: time.Now()})
+		c.pruneOlderThan(time.Minute)
+		require.Equal(t, 1, c.len())
+		require.Equal(t, 1, c.lru.Len())
+	})
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MeasureClockSkew compares the local clock with the Date headers returned by the status endpoints of all relays.
+// It returns the median of the local time minus the relay time, and the number of relays which returned a usable
+// Date header. The measurement is best-effort: relays are queried in parallel and unresponsive ones are skipped
+// after the timeout. Date headers have a resolution of one second.
+func (m *BoostService) MeasureClockSkew(timeout time.Duration) (skew time.Duration, numSamples int) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	samples := make([]time.Duration, 0, len(m.relays))
+
+	for _, relay := range m.relays {
+		wg.Add(1)
+		go func(relay RelayEntry) {
+			defer wg.Done()
+			url := relay.GetURI(pathStatus)
+			log := m.log.WithField("url", url)
+
+			req, err := newOutboundRequest(ctx, http.MethodGet, url, "", nil)
+			if err != nil {
+				log.WithError(err).Debug("could not prepare clock skew request")
+				return
+			}
+
+			sent := time.Now()
+			resp, err := m.httpClientGetHeader.Do(req)
+			if err != nil {
+				log.WithError(err).Debug("could not measure clock skew")
+				return
+			}
+			resp.Body.Close()
+			received := time.Now()
+
+			sample, ok := clockSkewFromDateHeader(resp.Header, sent, received)
+			if !ok {
+				log.Debug("relay returned no usable date header")
+				return
+			}
+
+			mu.Lock()
+			samples = append(samples, sample)
+			mu.Unlock()
+		}(relay)
+	}
+	wg.Wait()
+
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	return medianDuration(samples), len(samples)
+}
+
+// clockSkewFromDateHeader returns the local time minus the time of the Date header, using the midpoint between
+// sending the request and receiving the response as the local time. Missing or malformed headers are not an error.
+func clockSkewFromDateHeader(header http.Header, sent, received time.Time) (time.Duration, bool) {
+	date := header.Get("Date")
+	if date == "" {
+		return 0, false
+	}
+	remoteTime, err := http.ParseTime(date)
+	if err != nil {
+		return 0, false
+	}
+	localTime := sent.Add(received.Sub(sent) / 2)
+	return localTime.Sub(remoteTime), true
+}
+
+// medianDuration returns the median of a non-empty list of durations, sorting it in place
+func medianDuration(durations []time.Duration) time.Duration {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	mid := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[mid-1] + durations[mid]) / 2
+	}
+	return durations[mid]
+}
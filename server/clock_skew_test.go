@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMedianDuration(t *testing.T) {
+	require.Equal(t, 2*time.Second, medianDuration([]time.Duration{3 * time.Second, time.Second, 2 * time.Second}))
+	require.Equal(t, 1500*time.Millisecond, medianDuration([]time.Duration{2 * time.Second, time.Second}))
+	require.Equal(t, -time.Second, medianDuration([]time.Duration{-time.Second}))
+}
+
+func TestClockSkewFromDateHeader(t *testing.T) {
+	now := time.Now()
+
+	header := http.Header{}
+	_, ok := clockSkewFromDateHeader(header, now, now)
+	require.False(t, ok)
+
+	header.Set("Date", "yesterday")
+	_, ok = clockSkewFromDateHeader(header, now, now)
+	require.False(t, ok)
+
+	header.Set("Date", now.Add(-time.Hour).UTC().Format(http.TimeFormat))
+	skew, ok := clockSkewFromDateHeader(header, now, now)
+	require.True(t, ok)
+	require.InDelta(t, time.Hour, skew, float64(time.Second))
+}
+
+// newDateServer starts a server whose Date header is offset from the local clock, and points the relay at it
+func newDateServer(t *testing.T, relay *RelayEntry, offset time.Duration) {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+	}))
+	t.Cleanup(ts.Close)
+	relay.URL.Host = ts.Listener.Addr().String()
+}
+
+func TestMeasureClockSkew(t *testing.T) {
+	t.Run("relays with accurate clocks", func(t *testing.T) {
+		backend := newTestBackend(t, 3, time.Second)
+		skew, numSamples := backend.boost.MeasureClockSkew(time.Second)
+		require.Equal(t, 3, numSamples)
+		require.Less(t, skew.Abs(), 2*time.Second)
+	})
+
+	t.Run("median ignores a single skewed relay", func(t *testing.T) {
+		backend := newTestBackend(t, 3, time.Second)
+		newDateServer(t, &backend.boost.relays[0], time.Hour)
+		skew, numSamples := backend.boost.MeasureClockSkew(time.Second)
+		require.Equal(t, 3, numSamples)
+		require.Less(t, skew.Abs(), 2*time.Second)
+	})
+
+	t.Run("skewed local clock", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		for i := range backend.boost.relays {
+			newDateServer(t, &backend.boost.relays[i], -time.Hour)
+		}
+		skew, numSamples := backend.boost.MeasureClockSkew(time.Second)
+		require.Equal(t, 2, numSamples)
+		require.InDelta(t, time.Hour, skew, float64(2*time.Second))
+	})
+
+	t.Run("unresponsive relays are skipped after the timeout", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.relays[0].ResponseDelay = 500 * time.Millisecond
+		start := time.Now()
+		_, numSamples := backend.boost.MeasureClockSkew(50 * time.Millisecond)
+		require.Equal(t, 0, numSamples)
+		require.Less(t, time.Since(start), 400*time.Millisecond)
+	})
+
+	t.Run("relay without date header", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header()["Date"] = nil // suppresses the date header set by the server
+		}))
+		defer ts.Close()
+
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.relays[0].URL.Host = ts.Listener.Addr().String()
+		_, numSamples := backend.boost.MeasureClockSkew(time.Second)
+		require.Equal(t, 0, numSamples)
+	})
+}
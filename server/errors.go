@@ -5,5 +5,42 @@ import "fmt"
 // ErrMissingRelayPubkey is returned if a new RelayEntry URL has no public key.
 var ErrMissingRelayPubkey = fmt.Errorf("missing relay public key")
 
+// ErrRelayURLPassword is returned if a new RelayEntry URL has a password, the userinfo only holds the public key.
+var ErrRelayURLPassword = fmt.Errorf("relay URL must not contain a password")
+
+// ErrRelayMonitorURLPassword is returned if a new RelayMonitorEntry URL has a password, it would end up in the logs.
+var ErrRelayMonitorURLPassword = fmt.Errorf("relay monitor URL must not contain a password")
+
 // ErrPointAtInfinityPubkey is returned if a new RelayEntry URL has an all-zero public key.
 var ErrPointAtInfinityPubkey = fmt.Errorf("relay public key cannot be the point-at-infinity")
+
+// ErrInvalidRelayPubkey is returned if a relay public key is not a valid BLS public key.
+var ErrInvalidRelayPubkey = fmt.Errorf("invalid relay public key")
+
+// ErrInvalidRelayResolveAddr is returned if a new RelayEntry URL has a resolve argument which is not an IP:PORT pair.
+var ErrInvalidRelayResolveAddr = fmt.Errorf("relay resolve address must be an IP:PORT pair")
+
+// ErrConflictingRelayResolveAddr is returned if relays with the same host and port are pinned to different addresses.
+var ErrConflictingRelayResolveAddr = fmt.Errorf("conflicting resolve addresses for the same relay host")
+
+// ErrInvalidRelaySPKIPin is returned if a new RelayEntry URL has a pin-sha256 argument which is not a hex encoded
+// SHA-256 hash.
+var ErrInvalidRelaySPKIPin = fmt.Errorf("relay pin-sha256 must be a hex encoded SHA-256 hash")
+
+// ErrInvalidRelayWeight is returned if a new RelayEntry URL has a weight argument which is not a non-negative integer.
+var ErrInvalidRelayWeight = fmt.Errorf("relay weight must be a non-negative integer")
+
+// ErrInvalidRelayJSON is returned if a new RelayEntry in the JSON form cannot be parsed.
+var ErrInvalidRelayJSON = fmt.Errorf("invalid relay JSON, expected {\"url\": \"...\", \"headers\": {...}}")
+
+// ErrInvalidRelayHeader is returned if a new RelayEntry in the JSON form has a header with an invalid name or value.
+var ErrInvalidRelayHeader = fmt.Errorf("invalid relay request header")
+
+// ErrConflictingRelayHeaders is returned if relays with the same host have different request headers.
+var ErrConflictingRelayHeaders = fmt.Errorf("conflicting request headers for the same relay host")
+
+// ErrConflictingRelaySPKIPins is returned if relays with the same host are pinned to different certificates.
+var ErrConflictingRelaySPKIPins = fmt.Errorf("conflicting pin-sha256 arguments for the same relay host")
+
+// ErrRelaySPKIPinMismatch is returned if a relay presents a TLS certificate which does not match any of its pins.
+var ErrRelaySPKIPinMismatch = fmt.Errorf("relay TLS certificate does not match the pinned public keys")
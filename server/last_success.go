@@ -0,0 +1,48 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// proposalStep is a step of the proposal flow whose last success is reported on the status endpoint
+type proposalStep int
+
+const (
+	stepRegisterValidator proposalStep = iota // a registration was accepted by at least one relay
+	stepGetHeader                             // a bid was returned to the beacon node
+	stepGetPayload                            // a payload was delivered to the beacon node
+	numProposalSteps
+)
+
+var proposalStepNames = [numProposalSteps]string{"registerValidator", "getHeader", "getPayload"}
+
+// lastSuccessTracker keeps the Unix time of the last success of each proposal step, so that alerts can fire on the
+// time since then. Every step starts out at the start time, a restarted instance does not look like it never succeeded.
+type lastSuccessTracker struct {
+	timestamps [numProposalSteps]int64 // updated atomically
+}
+
+func newLastSuccessTracker(start time.Time) *lastSuccessTracker {
+	t := &lastSuccessTracker{}
+	for i := range t.timestamps {
+		t.timestamps[i] = start.Unix()
+	}
+	return t
+}
+
+// record sets the last success of the step
+func (t *lastSuccessTracker) record(step proposalStep, now time.Time) {
+	atomic.StoreInt64(&t.timestamps[step], now.Unix())
+}
+
+// String returns the last success of every step, e.g. "registerValidator=1700000000, getHeader=1700000012, ..."
+func (t *lastSuccessTracker) String() string {
+	values := make([]string, numProposalSteps)
+	for i, name := range proposalStepNames {
+		values[i] = name + "=" + strconv.FormatInt(atomic.LoadInt64(&t.timestamps[i]), 10)
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastSuccessTracker(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	tracker := newLastSuccessTracker(start)
+	require.Equal(t, "registerValidator=1700000000, getHeader=1700000000, getPayload=1700000000", tracker.String())
+
+	tracker.record(stepGetHeader, start.Add(12*time.Second))
+	tracker.record(stepGetPayload, start.Add(13*time.Second))
+	require.Equal(t, "registerValidator=1700000000, getHeader=1700000012, getPayload=1700000013", tracker.String())
+}
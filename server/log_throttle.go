@@ -0,0 +1,98 @@
+package server
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logThrottleMaxEntries bounds the number of distinct warnings remembered by the log throttler
+const logThrottleMaxEntries = 1_000
+
+type throttledLog struct {
+	key           string
+	lastEmitted   time.Time
+	numSuppressed int
+}
+
+// logThrottler emits identical warnings at most once per window, so that a relay which is down does not flood the
+// logs with the same warning for every slot. Warnings are identical if their message and key fields are. The number
+// of suppressed warnings is added to the next emitted one. A window of 0 disables throttling.
+type logThrottler struct {
+	mu         sync.Mutex
+	window     time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List // most recently emitted or suppressed warnings at the front
+}
+
+func newLogThrottler(window time.Duration, maxEntries int) *logThrottler {
+	return &logThrottler{
+		window:     window,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// allow returns whether the warning with the given key should be emitted now, and if so how many identical warnings
+// were suppressed since it was last emitted
+func (t *logThrottler) allow(key string, now time.Time) (emit bool, numSuppressed int) {
+	if t.window <= 0 {
+		return true, 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.entries[key]; ok {
+		t.lru.MoveToFront(el)
+		entry := el.Value.(*throttledLog) //nolint:forcetypeassert
+		if now.Sub(entry.lastEmitted) < t.window {
+			entry.numSuppressed++
+			return false, 0
+		}
+		numSuppressed = entry.numSuppressed
+		entry.lastEmitted = now
+		entry.numSuppressed = 0
+		return true, numSuppressed
+	}
+
+	t.entries[key] = t.lru.PushFront(&throttledLog{key: key, lastEmitted: now})
+	for t.lru.Len() > t.maxEntries {
+		oldest := t.lru.Back()
+		t.lru.Remove(oldest)
+		delete(t.entries, oldest.Value.(*throttledLog).key) //nolint:forcetypeassert
+	}
+	return true, 0
+}
+
+// log logs the message at the given level, unless an identical message was emitted within the window
+func (t *logThrottler) log(log *logrus.Entry, level logrus.Level, msg string, keyFields ...any) {
+	key := make([]string, 0, len(keyFields)+1)
+	key = append(key, msg)
+	for _, field := range keyFields {
+		key = append(key, fmt.Sprint(field))
+	}
+
+	emit, numSuppressed := t.allow(strings.Join(key, "|"), time.Now())
+	if !emit {
+		return
+	}
+	if numSuppressed > 0 {
+		log = log.WithField("numSuppressed", numSuppressed)
+	}
+	log.Log(level, msg)
+}
+
+func (t *logThrottler) warn(log *logrus.Entry, msg string, keyFields ...any) {
+	t.log(log, logrus.WarnLevel, msg, keyFields...)
+}
+
+func (t *logThrottler) error(log *logrus.Entry, msg string, keyFields ...any) {
+	t.log(log, logrus.ErrorLevel, msg, keyFields...)
+}
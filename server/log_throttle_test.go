@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogThrottler(t *testing.T) {
+	t.Run("emits identical warnings once per window", func(t *testing.T) {
+		throttle := newLogThrottler(time.Minute, 10)
+		now := time.Now()
+
+		emit, _ := throttle.allow("relay down", now)
+		require.True(t, emit)
+		for i := 1; i <= 3; i++ {
+			emit, _ = throttle.allow("relay down", now.Add(time.Duration(i)*time.Second))
+			require.False(t, emit)
+		}
+
+		// Other warnings are not affected
+		emit, _ = throttle.allow("other relay down", now)
+		require.True(t, emit)
+
+		emit, numSuppressed := throttle.allow("relay down", now.Add(time.Minute))
+		require.True(t, emit)
+		require.Equal(t, 3, numSuppressed)
+
+		emit, _ = throttle.allow("relay down", now.Add(time.Minute+time.Second))
+		require.False(t, emit)
+	})
+
+	t.Run("zero window disables throttling", func(t *testing.T) {
+		throttle := newLogThrottler(0, 10)
+		for i := 0; i < 3; i++ {
+			emit, numSuppressed := throttle.allow("relay down", time.Now())
+			require.True(t, emit)
+			require.Equal(t, 0, numSuppressed)
+		}
+		require.Equal(t, 0, throttle.lru.Len())
+	})
+
+	t.Run("memory stays bounded", func(t *testing.T) {
+		throttle := newLogThrottler(time.Minute, 10)
+		now := time.Now()
+		for i := 0; i < 100; i++ {
+			throttle.allow(fmt.Sprintf("relay %d down", i), now)
+		}
+		require.Equal(t, 10, throttle.lru.Len())
+		require.Len(t, throttle.entries, 10)
+
+		// The oldest warnings were forgotten, so they are emitted again
+		emit, _ := throttle.allow("relay 0 down", now)
+		require.True(t, emit)
+		emit, _ = throttle.allow("relay 99 down", now)
+		require.False(t, emit)
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		throttle := newLogThrottler(time.Minute, 10)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		numEmitted := 0
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if emit, _ := throttle.allow("relay down", time.Now()); emit {
+					mu.Lock()
+					numEmitted++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		require.Equal(t, 1, numEmitted)
+	})
+
+	t.Run("logs with key fields and suppressed count", func(t *testing.T) {
+		logger, hook := logrustest.NewNullLogger()
+		log := logrus.NewEntry(logger)
+		throttle := newLogThrottler(time.Minute, 10)
+
+		throttle.warn(log, "error making request to relay", "https://relay1.example.com")
+		throttle.warn(log, "error making request to relay", "https://relay1.example.com")
+		throttle.warn(log, "error making request to relay", "https://relay2.example.com")
+		throttle.error(log, "relay status error - request failed", "https://relay1.example.com")
+		require.Len(t, hook.AllEntries(), 3)
+		require.Equal(t, logrus.WarnLevel, hook.AllEntries()[0].Level)
+		require.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+
+		// Pretend the window has passed
+		el := throttle.entries["error making request to relay|https://relay1.example.com"]
+		el.Value.(*throttledLog).lastEmitted = time.Now().Add(-time.Hour) //nolint:forcetypeassert
+		throttle.warn(log, "error making request to relay", "https://relay1.example.com")
+		require.Len(t, hook.AllEntries(), 4)
+		require.Equal(t, 1, hook.LastEntry().Data["numSuppressed"])
+	})
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// headResponseWriter discards the body, so that HEAD requests get the same status and headers as GET requests
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// headMiddleware serves HEAD requests with the handler of the route, without a body
+func headMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodHead {
+			w = headResponseWriter{w}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// optionsMiddleware answers OPTIONS requests with the methods allowed on the route, without calling its handler
+func optionsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodOptions {
+			next.ServeHTTP(w, req)
+			return
+		}
+		route := mux.CurrentRoute(req)
+		if route == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// corsMiddleware adds CORS headers to responses for the allowed origins. A "*" origin allows all origins.
+func corsMiddleware(allowedOrigins []string) mux.MiddlewareFunc {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			if origin != "" && (allowed[origin] || allowed["*"]) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+				if req.Method == http.MethodOptions {
+					w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+				}
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// gzipMinSize is the minimum size of a response body to compress it, smaller bodies are not worth the overhead
+const gzipMinSize = 1024
+
+// gzipResponseWriter holds back the status code and the start of the body until it is known whether the body reaches
+// gzipMinSize, and compresses the body if it does
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	code    int
+	buf     []byte
+	decided bool         // whether the status code and the start of the body were written
+	gz      *gzip.Writer // set if the body is compressed
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= gzipMinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// decide writes the status code and the start of the body, compressed if compress is set
+func (w *gzipResponseWriter) decide(compress bool) error {
+	w.decided = true
+	if compress && w.Header().Get("Content-Encoding") == "" {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	if w.gz != nil {
+		_, err := w.gz.Write(w.buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(w.buf)
+	return err
+}
+
+// close writes what is still held back, once the handler is done
+func (w *gzipResponseWriter) close() error {
+	if !w.decided {
+		return w.decide(false)
+	}
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
+
+// gzipMiddleware compresses response bodies of at least gzipMinSize for clients which accept gzip. getHeader responses
+// are only compressed if compressGetHeader is set, as compressing them adds latency to the auction.
+func gzipMiddleware(compressGetHeader bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !compressGetHeader {
+				if route := mux.CurrentRoute(req); route != nil {
+					if template, err := route.GetPathTemplate(); err == nil && template == pathGetHeader {
+						next.ServeHTTP(w, req)
+						return
+					}
+				}
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			gw := &gzipResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(gw, req)
+			_ = gw.close() // the client is gone, there is no one to tell
+		})
+	}
+}
+
+// acceptsGzip returns whether the Accept-Encoding header of the request lists gzip, and does not rule it out with q=0
+func acceptsGzip(req *http.Request) bool {
+	for _, part := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		if strings.HasPrefix(q, "q=0") && strings.TrimRight(strings.TrimPrefix(q, "q=0"), ".0") == "" {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// allowedCIDRsMiddleware rejects requests from clients outside of the allowed prefixes with 403, before the request
+// body is read. The client address is the address of the connection.
+func (m *BoostService) allowedCIDRsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		addrPort, err := netip.ParseAddrPort(req.RemoteAddr)
+		if err == nil && addrAllowed(addrPort.Addr(), m.apiAllowedCIDRs) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		numRejectedClients := atomic.AddUint64(&m.numRejectedClients, 1)
+		m.logThrottle.warn(m.log.WithFields(logrus.Fields{
+			"remoteAddr":         req.RemoteAddr,
+			"numRejectedClients": numRejectedClients,
+		}), "rejected request from a client address which is not allowed", addrPort.Addr())
+		m.respondError(w, http.StatusForbidden, errClientNotAllowed.Error())
+	})
+}
+
+// addrAllowed returns whether the address is within one of the prefixes. IPv4-mapped IPv6 addresses are treated as
+// IPv4 addresses.
+func addrAllowed(addr netip.Addr, prefixes []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
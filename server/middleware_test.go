@@ -0,0 +1,262 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMethods(t *testing.T) {
+	hash := _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7")
+	pubkey := _HexToPubkey(
+		"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+	headerPath := getHeaderPath(1, hash, pubkey)
+
+	tests := []struct {
+		path          string
+		method        string
+		expectedCode  int
+		expectedBody  bool
+		expectedAllow string
+	}{
+		{pathStatus, http.MethodGet, http.StatusOK, true, ""},
+		{pathStatus, http.MethodHead, http.StatusOK, false, ""},
+		{pathStatus, http.MethodOptions, http.StatusNoContent, false, "GET, HEAD, OPTIONS"},
+		{pathStatus, http.MethodPost, http.StatusMethodNotAllowed, false, ""},
+		{pathRegisterValidator, http.MethodOptions, http.StatusNoContent, false, "POST, OPTIONS"},
+		{pathRegisterValidator, http.MethodGet, http.StatusMethodNotAllowed, false, ""},
+		{headerPath, http.MethodGet, http.StatusOK, true, ""},
+		{headerPath, http.MethodOptions, http.StatusNoContent, false, "GET, OPTIONS"},
+		{headerPath, http.MethodPost, http.StatusMethodNotAllowed, false, ""},
+		{pathGetPayload, http.MethodOptions, http.StatusNoContent, false, "POST, OPTIONS"},
+		{pathGetPayload, http.MethodGet, http.StatusMethodNotAllowed, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			backend := newTestBackend(t, 1, time.Second)
+			rr := backend.request(t, tt.method, tt.path, nil)
+			require.Equal(t, tt.expectedCode, rr.Code, rr.Body.String())
+			if tt.expectedBody {
+				require.NotEmpty(t, rr.Body.String())
+			} else {
+				require.Empty(t, rr.Body.String())
+			}
+			require.Equal(t, tt.expectedAllow, rr.Header().Get("Allow"))
+		})
+	}
+
+	t.Run("HEAD has the same headers as GET", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		rrGet := backend.request(t, http.MethodGet, pathStatus, nil)
+		rrHead := backend.request(t, http.MethodHead, pathStatus, nil)
+		require.Equal(t, rrGet.Header(), rrHead.Header())
+	})
+
+	t.Run("HEAD reports unavailable relays", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.relays[0].Server.Close()
+		rr := backend.request(t, http.MethodHead, pathStatus, nil)
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		require.Empty(t, rr.Body.String())
+	})
+
+	t.Run("OPTIONS does not call the handler", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		rr := backend.request(t, http.MethodOptions, headerPath, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Equal(t, 0, backend.relays[0].GetRequestCount(headerPath))
+	})
+}
+
+func TestCORS(t *testing.T) {
+	requestWithOrigin := func(backend *testBackend, method, origin string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, pathStatus, nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", origin)
+		rr := httptest.NewRecorder()
+		backend.boost.getRouter().ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		rr := requestWithOrigin(backend, http.MethodGet, "https://admin.example.com")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("allowed origin", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.corsAllowedOrigins = []string{"https://admin.example.com"}
+
+		rr := requestWithOrigin(backend, http.MethodGet, "https://admin.example.com")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "https://admin.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "Origin", rr.Header().Get("Vary"))
+
+		rr = requestWithOrigin(backend, http.MethodOptions, "https://admin.example.com")
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Equal(t, "https://admin.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "GET,HEAD,OPTIONS", rr.Header().Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "Content-Type", rr.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.corsAllowedOrigins = []string{"https://admin.example.com"}
+		rr := requestWithOrigin(backend, http.MethodGet, "https://evil.example.com")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("all origins", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.corsAllowedOrigins = []string{"*"}
+		rr := requestWithOrigin(backend, http.MethodGet, "https://admin.example.com")
+		require.Equal(t, "https://admin.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestAllowedCIDRs(t *testing.T) {
+	tests := []struct {
+		remoteAddr   string
+		expectedCode int
+	}{
+		{"10.1.2.3:51234", http.StatusOK},
+		{"[::ffff:10.1.2.3]:51234", http.StatusOK},
+		{"[fd00::7]:51234", http.StatusOK},
+		{"192.168.1.1:51234", http.StatusForbidden},
+		{"[fd01::7]:51234", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remoteAddr, func(t *testing.T) {
+			backend := newTestBackend(t, 1, time.Second)
+			backend.boost.apiAllowedCIDRs = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/64")}
+
+			req := httptest.NewRequest(http.MethodGet, pathStatus, nil)
+			req.RemoteAddr = tt.remoteAddr
+			rr := httptest.NewRecorder()
+			backend.boost.getRouter().ServeHTTP(rr, req)
+			require.Equal(t, tt.expectedCode, rr.Code, rr.Body.String())
+			if tt.expectedCode == http.StatusForbidden {
+				require.Equal(t, 0, backend.relays[0].GetRequestCount(pathStatus))
+			}
+		})
+	}
+
+	t.Run("all clients are allowed without prefixes", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		req := httptest.NewRequest(http.MethodGet, pathStatus, nil)
+		req.RemoteAddr = "192.168.1.1:51234"
+		rr := httptest.NewRecorder()
+		backend.boost.getRouter().ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	hash := _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7")
+	pubkey := _HexToPubkey(
+		"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+	headerPath := getHeaderPath(1, hash, pubkey)
+
+	largeBody := strings.Repeat("{}", gzipMinSize)
+	smallBody := "{}"
+	newRouter := func(compressGetHeader bool) http.Handler {
+		respond := func(body string) http.HandlerFunc {
+			return func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				// Written in pieces, as the JSON encoder may do
+				for rest := body; rest != ""; {
+					n := 100
+					if n > len(rest) {
+						n = len(rest)
+					}
+					_, _ = w.Write([]byte(rest[:n]))
+					rest = rest[n:]
+				}
+			}
+		}
+		r := mux.NewRouter()
+		r.HandleFunc(pathGetPayload, respond(largeBody))
+		r.HandleFunc(pathGetHeader, respond(largeBody))
+		r.HandleFunc(pathStatus, respond(smallBody))
+		r.Use(gzipMiddleware(compressGetHeader))
+		return r
+	}
+
+	tests := []struct {
+		name              string
+		path              string
+		acceptEncoding    string
+		compressGetHeader bool
+		expectedGzip      bool
+		expectedVary      bool
+		expectedBody      string
+	}{
+		{"client accepts gzip", pathGetPayload, "gzip, deflate", false, true, true, largeBody},
+		{"client accepts gzip with quality", pathGetPayload, "br;q=1.0, GZIP;q=0.5", false, true, true, largeBody},
+		{"client rules out gzip", pathGetPayload, "gzip;q=0, deflate", false, false, true, largeBody},
+		{"client does not accept gzip", pathGetPayload, "", false, false, true, largeBody},
+		{"small response", pathStatus, "gzip", false, false, true, smallBody},
+		{"getHeader is not compressed by default", headerPath, "gzip", false, false, false, largeBody},
+		{"getHeader compression enabled", headerPath, "gzip", true, true, true, largeBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+			newRouter(tt.compressGetHeader).ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code)
+			require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			if tt.expectedVary {
+				require.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+			} else {
+				require.Empty(t, rr.Header().Get("Vary"))
+			}
+
+			body := rr.Body.Bytes()
+			if tt.expectedGzip {
+				require.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+				gz, err := gzip.NewReader(rr.Body)
+				require.NoError(t, err)
+				body, err = io.ReadAll(gz)
+				require.NoError(t, err)
+			} else {
+				require.Empty(t, rr.Header().Get("Content-Encoding"))
+			}
+			require.Equal(t, tt.expectedBody, string(body))
+		})
+	}
+
+	t.Run("status code without body", func(t *testing.T) {
+		r := mux.NewRouter()
+		r.HandleFunc(pathStatus, func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		r.Use(gzipMiddleware(false))
+		req := httptest.NewRequest(http.MethodGet, pathStatus, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Empty(t, rr.Header().Get("Content-Encoding"))
+		require.Empty(t, rr.Body.String())
+	})
+}
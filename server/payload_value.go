@@ -0,0 +1,168 @@
+package server
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	boostTypes "github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+)
+
+// payloadValueMaxConsecutiveBelowClaimed is the number of consecutive payloads of a relay which pay the proposer less
+// than the bid claimed, after which the relay is reported as persistently underpaying
+const payloadValueMaxConsecutiveBelowClaimed = 3
+
+// observedProposerPayment returns the value of the last transaction of the block, if it was sent by the block's fee
+// recipient to the proposer's fee recipient. This is how builders pay the proposer. If the block has no such
+// transaction, for example because the proposer is the fee recipient, the payment can't be observed and false is
+// returned.
+func observedProposerPayment(feeRecipient, proposerFeeRecipient common.Address, txs [][]byte) (*big.Int, bool) {
+	if len(txs) == 0 {
+		return nil, false
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(txs[len(txs)-1]); err != nil {
+		return nil, false
+	}
+
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	}
+	sender, err := types.Sender(signer, tx)
+	if err != nil || sender != feeRecipient || tx.To() == nil || *tx.To() != proposerFeeRecipient {
+		return nil, false
+	}
+	return tx.Value(), true
+}
+
+// proposerFeeRecipients keeps the fee recipient of every validator, from its latest registration
+type proposerFeeRecipients struct {
+	mu            sync.Mutex
+	feeRecipients map[string]common.Address // by pubkey, as 0x-prefixed lowercase hex
+}
+
+func newProposerFeeRecipients() *proposerFeeRecipients {
+	return &proposerFeeRecipients{
+		feeRecipients: make(map[string]common.Address),
+	}
+}
+
+func (r *proposerFeeRecipients) record(registrations []boostTypes.SignedValidatorRegistration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, registration := range registrations {
+		if registration.Message == nil {
+			continue
+		}
+		r.feeRecipients[registration.Message.Pubkey.String()] = common.Address(registration.Message.FeeRecipient)
+	}
+}
+
+// get returns the fee recipient of the validator, and false if it didn't register
+func (r *proposerFeeRecipients) get(pubkey string) (common.Address, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	feeRecipient, ok := r.feeRecipients[pubkey]
+	return feeRecipient, ok
+}
+
+type payloadValueStats struct {
+	numDeliveries         int
+	numObserved           int
+	numBelowClaimed       int
+	numConsecutiveBelow   int
+	totalClaimedObserved  *big.Int // sum of the claimed values of the payloads with an observed value
+	totalObservedPayments *big.Int
+}
+
+// payloadValueTracker compares the value claimed in the bids with the payment observed in the delivered payloads,
+// per relay
+type payloadValueTracker struct {
+	mu    sync.Mutex
+	stats map[string]*payloadValueStats
+}
+
+func newPayloadValueTracker() *payloadValueTracker {
+	return &payloadValueTracker{
+		stats: make(map[string]*payloadValueStats),
+	}
+}
+
+// record adds a delivered payload of the relay. A nil claimed or observed value means it is unknown.
+func (t *payloadValueTracker) record(relay string, claimed, observed *big.Int) payloadValueStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats, ok := t.stats[relay]
+	if !ok {
+		stats = &payloadValueStats{
+			totalClaimedObserved:  new(big.Int),
+			totalObservedPayments: new(big.Int),
+		}
+		t.stats[relay] = stats
+	}
+
+	stats.numDeliveries++
+	if claimed != nil && observed != nil {
+		stats.numObserved++
+		stats.totalClaimedObserved.Add(stats.totalClaimedObserved, claimed)
+		stats.totalObservedPayments.Add(stats.totalObservedPayments, observed)
+		if observed.Cmp(claimed) < 0 {
+			stats.numBelowClaimed++
+			stats.numConsecutiveBelow++
+		} else {
+			stats.numConsecutiveBelow = 0
+		}
+	}
+
+	// Return a copy, so that it can be used without holding the lock
+	ret := *stats
+	ret.totalClaimedObserved = new(big.Int).Set(stats.totalClaimedObserved)
+	ret.totalObservedPayments = new(big.Int).Set(stats.totalObservedPayments)
+	return ret
+}
+
+// checkPayloadValue logs the value claimed in the bid against the payment to the proposer observed in the payload
+// delivered by the relay, and reports relays which persistently pay less than claimed. The payment is unknown if the
+// proposer's fee recipient is, i.e. the proposer didn't register through this instance.
+func (m *BoostService) checkPayloadValue(log *logrus.Entry, relay RelayEntry, claimed *big.Int, pubkey string, feeRecipient common.Address, txs [][]byte) {
+	var observed *big.Int
+	if proposerFeeRecipient, ok := m.proposerFeeRecipients.get(pubkey); ok {
+		observed, _ = observedProposerPayment(feeRecipient, proposerFeeRecipient, txs)
+	}
+	stats := m.payloadValues.record(relay.String(), claimed, observed)
+
+	log = log.WithFields(logrus.Fields{
+		"relay":                      relay.String(),
+		"claimedValue":               valueOrUnknown(claimed),
+		"observedValue":              valueOrUnknown(observed),
+		"numDeliveries":              stats.numDeliveries,
+		"numObserved":                stats.numObserved,
+		"numBelowClaimed":            stats.numBelowClaimed,
+		"numConsecutiveBelowClaimed": stats.numConsecutiveBelow,
+		"totalClaimedObserved":       stats.totalClaimedObserved.String(),
+		"totalObservedPayments":      stats.totalObservedPayments.String(),
+	})
+
+	switch {
+	case claimed == nil || observed == nil:
+		log.Debug("payload value could not be compared with the bid")
+	case observed.Cmp(claimed) >= 0:
+		log.Debug("payload pays the proposer at least the bid value")
+	case stats.numConsecutiveBelow >= payloadValueMaxConsecutiveBelowClaimed:
+		log.Error("relay persistently delivers payloads paying the proposer less than the bid value")
+	default:
+		log.Warn("payload pays the proposer less than the bid value")
+	}
+}
+
+func valueOrUnknown(value *big.Int) string {
+	if value == nil {
+		return "unknown"
+	}
+	return value.String()
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	boostTypes "github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObservedProposerPayment(t *testing.T) {
+	builderKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	builder := crypto.PubkeyToAddress(builderKey.PublicKey)
+	proposer := common.HexToAddress("0xdb65fEd33dc262Fe09D9a2Ba8F80b329BA25f941")
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+
+	signedTx := func(t *testing.T, to common.Address, value int64) []byte {
+		t.Helper()
+		tx, err := types.SignNewTx(builderKey, signer, &types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     1,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(100),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(value),
+		})
+		require.NoError(t, err)
+		txBytes, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		return txBytes
+	}
+
+	t.Run("payment in the last transaction", func(t *testing.T) {
+		txs := [][]byte{signedTx(t, common.Address{0x01}, 1), signedTx(t, proposer, 12345)}
+		value, ok := observedProposerPayment(builder, proposer, txs)
+		require.True(t, ok)
+		require.Equal(t, "12345", value.String())
+	})
+
+	t.Run("last transaction not sent by the fee recipient", func(t *testing.T) {
+		txs := [][]byte{signedTx(t, proposer, 12345)}
+		_, ok := observedProposerPayment(proposer, proposer, txs)
+		require.False(t, ok)
+	})
+
+	t.Run("last transaction not sent to the proposer's fee recipient", func(t *testing.T) {
+		txs := [][]byte{signedTx(t, proposer, 12345), signedTx(t, common.Address{0x01}, 1)}
+		_, ok := observedProposerPayment(builder, proposer, txs)
+		require.False(t, ok)
+	})
+
+	t.Run("no transactions", func(t *testing.T) {
+		_, ok := observedProposerPayment(builder, proposer, nil)
+		require.False(t, ok)
+	})
+
+	t.Run("invalid transaction", func(t *testing.T) {
+		_, ok := observedProposerPayment(builder, proposer, [][]byte{{0x01, 0x02}})
+		require.False(t, ok)
+	})
+}
+
+func TestProposerFeeRecipients(t *testing.T) {
+	feeRecipients := newProposerFeeRecipients()
+	pubkey := boostTypes.PublicKey{0x01}
+	_, ok := feeRecipients.get(pubkey.String())
+	require.False(t, ok)
+
+	feeRecipients.record([]boostTypes.SignedValidatorRegistration{makeTestRegistration(pubkey, boostTypes.Address{0x02}, 30_000_000, 1000)})
+	feeRecipient, ok := feeRecipients.get(pubkey.String())
+	require.True(t, ok)
+	require.Equal(t, common.Address{0x02}, feeRecipient)
+
+	// The latest registration wins
+	feeRecipients.record([]boostTypes.SignedValidatorRegistration{makeTestRegistration(pubkey, boostTypes.Address{0x03}, 30_000_000, 1001)})
+	feeRecipient, ok = feeRecipients.get(pubkey.String())
+	require.True(t, ok)
+	require.Equal(t, common.Address{0x03}, feeRecipient)
+}
+
+func TestPayloadValueTracker(t *testing.T) {
+	tracker := newPayloadValueTracker()
+	relay := "https://relay.example.com"
+
+	// Unknown values are counted as deliveries only
+	stats := tracker.record(relay, big.NewInt(100), nil)
+	require.Equal(t, 1, stats.numDeliveries)
+	require.Equal(t, 0, stats.numObserved)
+
+	for i := 1; i <= payloadValueMaxConsecutiveBelowClaimed; i++ {
+		stats = tracker.record(relay, big.NewInt(100), big.NewInt(90))
+		require.Equal(t, i, stats.numConsecutiveBelow)
+	}
+	require.Equal(t, payloadValueMaxConsecutiveBelowClaimed, stats.numBelowClaimed)
+	require.Equal(t, "300", stats.totalClaimedObserved.String())
+	require.Equal(t, "270", stats.totalObservedPayments.String())
+
+	// A payload paying at least the claimed value resets the consecutive count
+	stats = tracker.record(relay, big.NewInt(100), big.NewInt(100))
+	require.Equal(t, 0, stats.numConsecutiveBelow)
+	require.Equal(t, payloadValueMaxConsecutiveBelowClaimed, stats.numBelowClaimed)
+	require.Equal(t, payloadValueMaxConsecutiveBelowClaimed+2, stats.numDeliveries)
+
+	// Relays are tracked separately
+	stats = tracker.record("https://relay2.example.com", big.NewInt(100), big.NewInt(90))
+	require.Equal(t, 1, stats.numDeliveries)
+	require.Equal(t, 1, stats.numConsecutiveBelow)
+}
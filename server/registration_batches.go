@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// encodeRegistrations encodes the registrations once, the encoding is shared by the requests to all relays. A batch of
+// 50k registrations encodes to about 20 MB, which is held once per batch instead of once per relay.
+func encodeRegistrations(payload []types.SignedValidatorRegistration) (json.RawMessage, error) {
+	return json.Marshal(payload)
+}
+
+// registrationBatches limits the number of validator registration batches processed at the same time, and keeps track
+// of the memory they hold. A batch is in flight from decoding until the requests to all relays are done, which can
+// take up to the registerValidator timeout. A limit of 0 allows any number of batches.
+type registrationBatches struct {
+	slots chan struct{} // nil if unlimited
+
+	numInFlight   int64 // updated atomically
+	bufferedBytes int64 // size of the encoded batches in flight, updated atomically
+}
+
+func newRegistrationBatches(maxInFlight int) *registrationBatches {
+	b := &registrationBatches{}
+	if maxInFlight > 0 {
+		b.slots = make(chan struct{}, maxInFlight)
+	}
+	return b
+}
+
+// acquire returns whether another batch may be processed now. Each successful acquire must be followed by a release.
+func (b *registrationBatches) acquire() bool {
+	if b.slots != nil {
+		select {
+		case b.slots <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	atomic.AddInt64(&b.numInFlight, 1)
+	return true
+}
+
+// buffered adds the size of the encoded batch to the buffered bytes, until the batch is released
+func (b *registrationBatches) buffered(size int) {
+	atomic.AddInt64(&b.bufferedBytes, int64(size))
+}
+
+// release marks a batch as done, with the size of its encoding as passed to buffered, or 0
+func (b *registrationBatches) release(size int) {
+	atomic.AddInt64(&b.bufferedBytes, -int64(size))
+	atomic.AddInt64(&b.numInFlight, -1)
+	if b.slots != nil {
+		<-b.slots
+	}
+}
+
+// stats returns the number of batches in flight, and the size of their encodings
+func (b *registrationBatches) stats() (numInFlight, bufferedBytes int64) {
+	return atomic.LoadInt64(&b.numInFlight), atomic.LoadInt64(&b.bufferedBytes)
+}
+
+// registrationRetryAfterSec is the Retry-After of rejected registerValidator requests: batches in flight are done
+// within the registerValidator timeout
+func (m *BoostService) registrationRetryAfterSec() int {
+	sec := int((m.httpClientRegVal.Timeout + time.Second - 1) / time.Second)
+	if sec < 1 {
+		return 1
+	}
+	return sec
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationBatches(t *testing.T) {
+	t.Run("limits the batches in flight", func(t *testing.T) {
+		b := newRegistrationBatches(2)
+		require.True(t, b.acquire())
+		require.True(t, b.acquire())
+		require.False(t, b.acquire())
+
+		b.buffered(100)
+		numInFlight, bufferedBytes := b.stats()
+		require.Equal(t, int64(2), numInFlight)
+		require.Equal(t, int64(100), bufferedBytes)
+
+		b.release(100)
+		require.True(t, b.acquire())
+		numInFlight, bufferedBytes = b.stats()
+		require.Equal(t, int64(2), numInFlight)
+		require.Equal(t, int64(0), bufferedBytes)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		b := newRegistrationBatches(0)
+		for i := 0; i < 100; i++ {
+			require.True(t, b.acquire())
+		}
+	})
+}
+
+func TestEncodeRegistrations(t *testing.T) {
+	payload := []types.SignedValidatorRegistration{makeTestRegistration(types.PublicKey{0x01}, types.Address{0x02}, 30_000_000, 1000)}
+	expected, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	body, err := encodeRegistrations(payload)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(body))
+}
+
+// BenchmarkRegistrationForwarding compares the memory held while a 50k registration batch is forwarded to three relays,
+// reported as the heap in use with the requests to all relays in flight
+func BenchmarkRegistrationForwarding(b *testing.B) {
+	const numRelays = 3
+	payload := make([]types.SignedValidatorRegistration, 50_000)
+	for i := range payload {
+		payload[i] = makeTestRegistration(types.PublicKey{byte(i), byte(i >> 8)}, types.Address{0x01}, 30_000_000, uint64(i))
+		payload[i].Signature = types.Signature{0x02}
+	}
+
+	// inFlightHeap returns the heap in use while the bodies of the requests to all relays are held
+	inFlightHeap := func(b *testing.B, encode func() [][]byte) uint64 {
+		b.Helper()
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		bodies := encode()
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(bodies)
+		if after.HeapAlloc < before.HeapAlloc {
+			return 0
+		}
+		return after.HeapAlloc - before.HeapAlloc
+	}
+
+	b.Run("encoded per relay", func(b *testing.B) {
+		b.ReportAllocs()
+		var peak uint64
+		for i := 0; i < b.N; i++ {
+			heap := inFlightHeap(b, func() [][]byte {
+				bodies := make([][]byte, numRelays)
+				for j := range bodies {
+					body, err := json.Marshal(payload)
+					if err != nil {
+						b.Fatal(err)
+					}
+					bodies[j] = body
+				}
+				return bodies
+			})
+			if heap > peak {
+				peak = heap
+			}
+		}
+		b.ReportMetric(float64(peak)/1e6, "inflight-MB")
+	})
+
+	b.Run("encoded once", func(b *testing.B) {
+		b.ReportAllocs()
+		var peak uint64
+		for i := 0; i < b.N; i++ {
+			heap := inFlightHeap(b, func() [][]byte {
+				body, err := encodeRegistrations(payload)
+				if err != nil {
+					b.Fatal(err)
+				}
+				bodies := make([][]byte, numRelays)
+				for j := range bodies {
+					bodies[j] = body
+				}
+				return bodies
+			})
+			if heap > peak {
+				peak = heap
+			}
+		}
+		b.ReportMetric(float64(peak)/1e6, "inflight-MB")
+	})
+}
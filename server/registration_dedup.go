@@ -0,0 +1,110 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// registrationDigest is what identifies a validator registration for the purpose of deduplication
+type registrationDigest struct {
+	feeRecipient    types.Address
+	gasLimit        uint64
+	timestampBucket uint64
+}
+
+// forwardedRegistration is the digest of the registration last forwarded for a validator, and when it was forwarded
+type forwardedRegistration struct {
+	digest registrationDigest
+	at     time.Time
+}
+
+// registrationDeduplicator keeps track of the validator registrations last forwarded to the relay monitors, so that
+// unchanged re-registrations are forwarded at most once per heartbeat period. Relays are not affected by this.
+// Validators which stop registering are forgotten after a heartbeat period.
+type registrationDeduplicator struct {
+	heartbeat time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	forwarded map[types.PublicKey]forwardedRegistration
+	lastPrune time.Time
+
+	numForwarded  uint64
+	numSuppressed uint64
+}
+
+// newRegistrationDeduplicator creates a new deduplicator. A heartbeat of zero disables deduplication.
+func newRegistrationDeduplicator(heartbeat time.Duration) *registrationDeduplicator {
+	return &registrationDeduplicator{
+		heartbeat: heartbeat,
+		now:       time.Now,
+		forwarded: make(map[types.PublicKey]forwardedRegistration),
+	}
+}
+
+// filter returns the registrations which have changed since they were last forwarded, or for which the heartbeat
+// period has passed, and remembers them as forwarded
+func (d *registrationDeduplicator) filter(payload []types.SignedValidatorRegistration) []types.SignedValidatorRegistration {
+	if d.heartbeat <= 0 {
+		atomic.AddUint64(&d.numForwarded, uint64(len(payload)))
+		return payload
+	}
+
+	heartbeatSeconds := uint64(d.heartbeat / time.Second)
+	if heartbeatSeconds == 0 {
+		heartbeatSeconds = 1
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.prune(now)
+
+	ret := make([]types.SignedValidatorRegistration, 0, len(payload))
+	for _, registration := range payload {
+		if registration.Message == nil {
+			ret = append(ret, registration)
+			continue
+		}
+
+		digest := registrationDigest{
+			feeRecipient:    registration.Message.FeeRecipient,
+			gasLimit:        registration.Message.GasLimit,
+			timestampBucket: registration.Message.Timestamp / heartbeatSeconds,
+		}
+		if previous, ok := d.forwarded[registration.Message.Pubkey]; ok && previous.digest == digest {
+			continue
+		}
+
+		d.forwarded[registration.Message.Pubkey] = forwardedRegistration{digest: digest, at: now}
+		ret = append(ret, registration)
+	}
+
+	atomic.AddUint64(&d.numForwarded, uint64(len(ret)))
+	atomic.AddUint64(&d.numSuppressed, uint64(len(payload)-len(ret)))
+	return ret
+}
+
+// prune forgets the registrations forwarded more than a heartbeat period ago, at most once per heartbeat period.
+// Must be called with the lock held.
+func (d *registrationDeduplicator) prune(now time.Time) {
+	if now.Sub(d.lastPrune) < d.heartbeat {
+		return
+	}
+	d.lastPrune = now
+
+	for pubkey, previous := range d.forwarded {
+		if now.Sub(previous.at) > d.heartbeat {
+			delete(d.forwarded, pubkey)
+		}
+	}
+}
+
+// counts returns the total number of forwarded and suppressed registrations
+func (d *registrationDeduplicator) counts() (forwarded, suppressed uint64) {
+	return atomic.LoadUint64(&d.numForwarded), atomic.LoadUint64(&d.numSuppressed)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestRegistration(pubkey types.PublicKey, feeRecipient types.Address, gasLimit, timestamp uint64) types.SignedValidatorRegistration {
+	return types.SignedValidatorRegistration{
+		Message: &types.RegisterValidatorRequestMessage{
+			FeeRecipient: feeRecipient,
+			GasLimit:     gasLimit,
+			Timestamp:    timestamp,
+			Pubkey:       pubkey,
+		},
+	}
+}
+
+func TestRegistrationDeduplicator(t *testing.T) {
+	pubkey1 := types.PublicKey{0x01}
+	pubkey2 := types.PublicKey{0x02}
+	feeRecipient := types.Address{0x01}
+
+	t.Run("forwards everything when disabled", func(t *testing.T) {
+		d := newRegistrationDeduplicator(0)
+		payload := []types.SignedValidatorRegistration{makeTestRegistration(pubkey1, feeRecipient, 30_000_000, 1000)}
+		require.Len(t, d.filter(payload), 1)
+		require.Len(t, d.filter(payload), 1)
+
+		forwarded, suppressed := d.counts()
+		require.Equal(t, uint64(2), forwarded)
+		require.Equal(t, uint64(0), suppressed)
+	})
+
+	t.Run("suppresses unchanged registrations within the heartbeat", func(t *testing.T) {
+		d := newRegistrationDeduplicator(time.Hour)
+		require.Len(t, d.filter([]types.SignedValidatorRegistration{
+			makeTestRegistration(pubkey1, feeRecipient, 30_000_000, 3600),
+			makeTestRegistration(pubkey2, feeRecipient, 30_000_000, 3600),
+		}), 2)
+
+		// Re-registration one epoch later with the same settings
+		require.Len(t, d.filter([]types.SignedValidatorRegistration{
+			makeTestRegistration(pubkey1, feeRecipient, 30_000_000, 3600+384),
+			makeTestRegistration(pubkey2, feeRecipient, 30_000_000, 3600+384),
+		}), 0)
+
+		forwarded, suppressed := d.counts()
+		require.Equal(t, uint64(2), forwarded)
+		require.Equal(t, uint64(2), suppressed)
+	})
+
+	t.Run("forwards changed registrations", func(t *testing.T) {
+		d := newRegistrationDeduplicator(time.Hour)
+		require.Len(t, d.filter([]types.SignedValidatorRegistration{makeTestRegistration(pubkey1, feeRecipient, 30_000_000, 3600)}), 1)
+
+		// Changed gas limit
+		filtered := d.filter([]types.SignedValidatorRegistration{
+			makeTestRegistration(pubkey1, feeRecipient, 31_000_000, 3601),
+			makeTestRegistration(pubkey2, feeRecipient, 30_000_000, 3601),
+		})
+		require.Len(t, filtered, 2)
+
+		// Changed fee recipient
+		filtered = d.filter([]types.SignedValidatorRegistration{makeTestRegistration(pubkey1, types.Address{0x02}, 31_000_000, 3602)})
+		require.Len(t, filtered, 1)
+	})
+
+	t.Run("forwards unchanged registrations as heartbeat", func(t *testing.T) {
+		d := newRegistrationDeduplicator(time.Hour)
+		require.Len(t, d.filter([]types.SignedValidatorRegistration{makeTestRegistration(pubkey1, feeRecipient, 30_000_000, 3600)}), 1)
+		require.Len(t, d.filter([]types.SignedValidatorRegistration{makeTestRegistration(pubkey1, feeRecipient, 30_000_000, 7199)}), 0)
+		require.Len(t, d.filter([]types.SignedValidatorRegistration{makeTestRegistration(pubkey1, feeRecipient, 30_000_000, 7200)}), 1)
+	})
+	t.Run("forgets validators which stopped registering", func(t *testing.T) {
+		now := time.Unix(1_700_000_000, 0)
+		d := newRegistrationDeduplicator(time.Hour)
+		d.now = func() time.Time { return now }
+
+		require.Len(t, d.filter([]types.SignedValidatorRegistration{
+			makeTestRegistration(pubkey1, feeRecipient, 30_000_000, 3600),
+			makeTestRegistration(pubkey2, feeRecipient, 30_000_000, 3600),
+		}), 2)
+		require.Len(t, d.forwarded, 2)
+
+		// Only pubkey1 keeps registering
+		now = now.Add(30 * time.Minute)
+		require.Len(t, d.filter([]types.SignedValidatorRegistration{makeTestRegistration(pubkey1, feeRecipient, 31_000_000, 5400)}), 1)
+
+		now = now.Add(45 * time.Minute)
+		require.Len(t, d.filter([]types.SignedValidatorRegistration{makeTestRegistration(pubkey1, feeRecipient, 31_000_000, 5400)}), 0)
+		require.Len(t, d.forwarded, 1)
+		require.Contains(t, d.forwarded, pubkey1)
+	})
+}
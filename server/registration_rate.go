@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+const (
+	// registrationRateWindow is the window the registrations of a source are counted in. Validator clients register
+	// their validators once per epoch.
+	registrationRateWindow = slotsPerEpoch * secondsPerSlot * time.Second
+
+	// registrationRateMaxPerEpoch is the number of registrations per validator and epoch above which a source is
+	// reported, e.g. a validator client which re-registers every slot
+	registrationRateMaxPerEpoch = 2
+
+	// registrationRateMaxSources bounds the number of sources tracked at the same time
+	registrationRateMaxSources = 1_000
+)
+
+type sourceRegistrations struct {
+	windowStart      time.Time
+	numRegistrations int
+	validators       map[types.PublicKey]struct{}
+}
+
+// registrationRateTracker counts the validator registrations per source within the current epoch-long window, to find
+// validator clients which register their validators more often than once per epoch
+type registrationRateTracker struct {
+	mu      sync.Mutex
+	sources map[string]*sourceRegistrations
+}
+
+func newRegistrationRateTracker() *registrationRateTracker {
+	return &registrationRateTracker{
+		sources: make(map[string]*sourceRegistrations),
+	}
+}
+
+// record adds the registrations sent by the source, and returns the number of validators registered by the source in
+// the window and the number of registrations per validator. New sources are not tracked while the maximum number of
+// sources is tracked, 0 is returned for them.
+func (t *registrationRateTracker) record(source string, registrations []types.SignedValidatorRegistration, now time.Time) (numValidators int, perValidator float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.sources[source]
+	if !ok || now.Sub(s.windowStart) >= registrationRateWindow {
+		if !ok && len(t.sources) >= registrationRateMaxSources {
+			t.dropExpired(now)
+			if len(t.sources) >= registrationRateMaxSources {
+				return 0, 0
+			}
+		}
+		s = &sourceRegistrations{windowStart: now, validators: make(map[types.PublicKey]struct{})}
+		t.sources[source] = s
+	}
+
+	for _, registration := range registrations {
+		if registration.Message == nil {
+			continue
+		}
+		s.numRegistrations++
+		s.validators[registration.Message.Pubkey] = struct{}{}
+	}
+	if len(s.validators) == 0 {
+		return 0, 0
+	}
+	return len(s.validators), float64(s.numRegistrations) / float64(len(s.validators))
+}
+
+func (t *registrationRateTracker) dropExpired(now time.Time) {
+	for source, s := range t.sources {
+		if now.Sub(s.windowStart) >= registrationRateWindow {
+			delete(t.sources, source)
+		}
+	}
+}
+
+// registrationSource identifies the client which sent the registrations by the address of the connection
+func registrationSource(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationRateTracker(t *testing.T) {
+	tracker := newRegistrationRateTracker()
+	now := time.Now()
+	registrations := []types.SignedValidatorRegistration{
+		makeTestRegistration(types.PublicKey{0x01}, types.Address{0x01}, 30_000_000, 1000),
+		makeTestRegistration(types.PublicKey{0x02}, types.Address{0x01}, 30_000_000, 1000),
+	}
+
+	numValidators, perValidator := tracker.record("10.0.0.1", registrations, now)
+	require.Equal(t, 2, numValidators)
+	require.Equal(t, 1.0, perValidator)
+
+	// Re-registering every slot
+	for slot := 1; slot < 4; slot++ {
+		_, perValidator = tracker.record("10.0.0.1", registrations, now.Add(time.Duration(slot)*secondsPerSlot*time.Second))
+	}
+	require.Equal(t, 4.0, perValidator)
+
+	// Sources are counted separately
+	_, perValidator = tracker.record("10.0.0.2", registrations[:1], now)
+	require.Equal(t, 1.0, perValidator)
+
+	// The count starts over in the next window
+	numValidators, perValidator = tracker.record("10.0.0.1", registrations[:1], now.Add(registrationRateWindow))
+	require.Equal(t, 1, numValidators)
+	require.Equal(t, 1.0, perValidator)
+}
+
+func TestRegistrationSource(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/eth/v1/builder/validators", nil)
+	req.RemoteAddr = "10.0.0.1:51234"
+	require.Equal(t, "10.0.0.1", registrationSource(req))
+	req.RemoteAddr = "[2001:db8::1]:51234"
+	require.Equal(t, "2001:db8::1", registrationSource(req))
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// relayBreakerState is the circuit breaker state of a single relay
+type relayBreakerState struct {
+	numFailures int       // consecutive failed getHeader requests
+	openUntil   time.Time // the relay is skipped until then, zero while the relay is used
+	probing     bool      // the cooldown is over and a single probe request is in flight
+}
+
+// relayBreaker temporarily skips relays which failed several getHeader requests in a row, so that a relay which is
+// down does not use up the getHeader time budget of every slot. Once the cooldown is over, a single request probes the
+// relay: if it succeeds the relay is used again, otherwise it is skipped for another cooldown. No bid is a success,
+// only errors, timeouts and truncated responses are failures. A threshold of 0 disables the breaker.
+type relayBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	log       *logrus.Entry
+	relays    map[string]*relayBreakerState // keyed by relay URL, only relays with failures are tracked
+}
+
+func newRelayBreaker(threshold int, cooldown time.Duration, log *logrus.Entry) *relayBreaker {
+	return &relayBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		log:       log,
+		relays:    make(map[string]*relayBreakerState),
+	}
+}
+
+// selectRelays returns the relays to query now, and the number of skipped relays. If all relays would be skipped,
+// all of them are returned, so that a getHeader request is never answered without asking any relay.
+func (b *relayBreaker) selectRelays(relays []RelayEntry, now time.Time) (selected []RelayEntry, numSkipped int) {
+	if b.threshold <= 0 {
+		return relays, 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	selected = make([]RelayEntry, 0, len(relays))
+	for _, relay := range relays {
+		s, ok := b.relays[relay.String()]
+		switch {
+		case !ok || s.openUntil.IsZero():
+			selected = append(selected, relay)
+		case now.Before(s.openUntil) || s.probing:
+			// skipped until the cooldown is over, or until the probe request is done
+		default:
+			s.probing = true
+			selected = append(selected, relay)
+		}
+	}
+	if len(selected) == 0 {
+		return relays, 0
+	}
+	return selected, len(relays) - len(selected)
+}
+
+// record updates the state of the relay with the result of a getHeader request
+func (b *relayBreaker) record(relay string, failed bool, now time.Time) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	s, ok := b.relays[relay]
+	if !failed {
+		delete(b.relays, relay)
+		b.mu.Unlock()
+		if ok && !s.openUntil.IsZero() {
+			b.log.WithField("relay", relay).Info("relay responded again, using it for getHeader again")
+		}
+		return
+	}
+	if !ok {
+		s = &relayBreakerState{}
+		b.relays[relay] = s
+	}
+	s.numFailures++
+	s.probing = false
+	wasOpen := !s.openUntil.IsZero()
+	if wasOpen || s.numFailures >= b.threshold {
+		s.openUntil = now.Add(b.cooldown)
+	}
+	numFailures := s.numFailures
+	b.mu.Unlock()
+
+	if !wasOpen && numFailures >= b.threshold {
+		b.log.WithFields(logrus.Fields{
+			"relay":       relay,
+			"numFailures": numFailures,
+			"cooldown":    b.cooldown.String(),
+		}).Warn("relay failed too many getHeader requests in a row, skipping it until the cooldown is over")
+	}
+}
+
+// isFailure returns whether the outcome counts as a failure of the relay for the circuit breaker
+func (o getHeaderOutcome) isFailure() bool {
+	return o == getHeaderOutcomeError || o == getHeaderOutcomeTimeout || o == getHeaderOutcomeTruncated
+}
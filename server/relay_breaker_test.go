@@ -0,0 +1,97 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayBreaker(t *testing.T) {
+	relay1, err := NewRelayEntry("https://0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249@relay1.example.com")
+	require.NoError(t, err)
+	relay2, err := NewRelayEntry("https://0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249@relay2.example.com")
+	require.NoError(t, err)
+	relays := []RelayEntry{relay1, relay2}
+	now := time.Now()
+
+	t.Run("disabled", func(t *testing.T) {
+		breaker := newRelayBreaker(0, time.Minute, testLog)
+		for i := 0; i < 10; i++ {
+			breaker.record(relay1.String(), true, now)
+		}
+		selected, numSkipped := breaker.selectRelays(relays, now)
+		require.Equal(t, relays, selected)
+		require.Equal(t, 0, numSkipped)
+	})
+
+	t.Run("opens after consecutive failures and probes after the cooldown", func(t *testing.T) {
+		breaker := newRelayBreaker(3, time.Minute, testLog)
+
+		// A success in between resets the count
+		breaker.record(relay1.String(), true, now)
+		breaker.record(relay1.String(), true, now)
+		breaker.record(relay1.String(), false, now)
+		breaker.record(relay1.String(), true, now)
+		breaker.record(relay1.String(), true, now)
+		selected, _ := breaker.selectRelays(relays, now)
+		require.Equal(t, relays, selected)
+
+		breaker.record(relay1.String(), true, now)
+		selected, numSkipped := breaker.selectRelays(relays, now)
+		require.Equal(t, []RelayEntry{relay2}, selected)
+		require.Equal(t, 1, numSkipped)
+
+		// After the cooldown, a single request probes the relay
+		now := now.Add(time.Minute)
+		selected, _ = breaker.selectRelays(relays, now)
+		require.Equal(t, relays, selected)
+		selected, _ = breaker.selectRelays(relays, now)
+		require.Equal(t, []RelayEntry{relay2}, selected)
+
+		// A failed probe skips the relay for another cooldown
+		breaker.record(relay1.String(), true, now)
+		selected, _ = breaker.selectRelays(relays, now.Add(time.Second))
+		require.Equal(t, []RelayEntry{relay2}, selected)
+
+		// A successful probe closes the breaker
+		now = now.Add(time.Minute)
+		selected, _ = breaker.selectRelays(relays, now)
+		require.Equal(t, relays, selected)
+		breaker.record(relay1.String(), false, now)
+		selected, _ = breaker.selectRelays(relays, now)
+		require.Equal(t, relays, selected)
+		selected, _ = breaker.selectRelays(relays, now)
+		require.Equal(t, relays, selected)
+	})
+
+	t.Run("never skips all relays", func(t *testing.T) {
+		breaker := newRelayBreaker(1, time.Minute, testLog)
+		breaker.record(relay1.String(), true, now)
+		breaker.record(relay2.String(), true, now)
+		selected, numSkipped := breaker.selectRelays(relays, now)
+		require.Equal(t, relays, selected)
+		require.Equal(t, 0, numSkipped)
+	})
+
+	t.Run("skips a failing relay in getHeader", func(t *testing.T) {
+		hash := _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7")
+		pubkey := _HexToPubkey(
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+		path := getHeaderPath(1, hash, pubkey)
+
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.relayBreaker = newRelayBreaker(2, time.Minute, testLog)
+		backend.relays[0].handlerOverrideGetHeader = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+
+		for i := 0; i < 3; i++ {
+			rr := backend.request(t, http.MethodGet, path, nil)
+			require.Equal(t, http.StatusOK, rr.Code)
+		}
+		require.Equal(t, 2, backend.relays[0].GetRequestCount(path))
+		require.Equal(t, 3, backend.relays[1].GetRequestCount(path))
+	})
+}
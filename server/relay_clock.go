@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// relayClockSkewSamples is the number of recent responses per relay the rolling median clock skew is computed over
+const relayClockSkewSamples = 15
+
+type relayClockSkew struct {
+	samples    [relayClockSkewSamples]time.Duration
+	numSamples int  // total number of samples recorded, the ring buffer holds the most recent ones
+	exceeded   bool // whether the median exceeded the threshold when it was last computed
+}
+
+// median returns the median of the samples in the ring buffer
+func (s *relayClockSkew) median() time.Duration {
+	n := s.numSamples
+	if n > relayClockSkewSamples {
+		n = relayClockSkewSamples
+	}
+	samples := make([]time.Duration, n)
+	copy(samples, s.samples[:n])
+	return medianDuration(samples)
+}
+
+// relayClockTracker measures the clock skew of every relay from the Date headers of its responses, and keeps a
+// rolling median per relay, which the status endpoint reports. It warns when the median exceeds the threshold, and
+// again when it is back within. Missing or malformed Date headers are ignored.
+type relayClockTracker struct {
+	next      http.RoundTripper
+	log       *logrus.Entry
+	threshold time.Duration   // 0 disables the warnings
+	relays    map[string]bool // hosts of the relays, the clocks of the relay monitors are not measured
+	mu        sync.Mutex
+	skews     map[string]*relayClockSkew
+}
+
+func newRelayClockTracker(next http.RoundTripper, relays []RelayEntry, threshold time.Duration, log *logrus.Entry) *relayClockTracker {
+	hosts := make(map[string]bool, len(relays))
+	for _, relay := range relays {
+		hosts[relay.URL.Host] = true
+	}
+	return &relayClockTracker{
+		next:      next,
+		log:       log,
+		threshold: threshold,
+		relays:    hosts,
+		skews:     make(map[string]*relayClockSkew),
+	}
+}
+
+func (t *relayClockTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && t.relays[req.URL.Host] {
+		if skew, ok := clockSkewFromDateHeader(resp.Header, sent, time.Now()); ok {
+			t.record(req.URL.Host, skew)
+		}
+	}
+	return resp, err
+}
+
+func (t *relayClockTracker) record(host string, skew time.Duration) {
+	t.mu.Lock()
+	s, ok := t.skews[host]
+	if !ok {
+		s = &relayClockSkew{}
+		t.skews[host] = s
+	}
+	s.samples[s.numSamples%relayClockSkewSamples] = skew
+	s.numSamples++
+
+	if t.threshold <= 0 {
+		t.mu.Unlock()
+		return
+	}
+	median := s.median()
+	exceeded := median > t.threshold || median < -t.threshold
+	changed := exceeded != s.exceeded
+	s.exceeded = exceeded
+	t.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	log := t.log.WithFields(logrus.Fields{
+		"relayHost": host,
+		"skew":      median.String(),
+		"threshold": t.threshold.String(),
+	})
+	if exceeded {
+		log.Warn("clock skew between mev-boost and the relay exceeds the threshold, please check the local clock")
+	} else {
+		log.Info("clock skew between mev-boost and the relay is back within the threshold")
+	}
+}
+
+// median returns the rolling median clock skew of the relay host, i.e. the local time minus the relay time
+func (t *relayClockTracker) median(host string) (skew time.Duration, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.skews[host]
+	if !ok {
+		return 0, false
+	}
+	return s.median(), true
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayClockTracker(t *testing.T) {
+	t.Run("rolling median and threshold warnings", func(t *testing.T) {
+		logger, hook := logrustest.NewNullLogger()
+		tracker := newRelayClockTracker(http.DefaultTransport, nil, 5*time.Second, logrus.NewEntry(logger))
+
+		_, ok := tracker.median("relay")
+		require.False(t, ok)
+
+		// A single outlier does not move the median
+		tracker.record("relay", time.Second)
+		tracker.record("relay", 2*time.Second)
+		tracker.record("relay", time.Minute)
+		skew, ok := tracker.median("relay")
+		require.True(t, ok)
+		require.Equal(t, 2*time.Second, skew)
+		require.Empty(t, hook.AllEntries())
+
+		// Once most of the recent samples are off, the median exceeds the threshold and a warning is logged once
+		for i := 0; i < relayClockSkewSamples; i++ {
+			tracker.record("relay", -10*time.Second)
+		}
+		skew, _ = tracker.median("relay")
+		require.Equal(t, -10*time.Second, skew)
+		require.Len(t, hook.AllEntries(), 1)
+		require.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+		require.Equal(t, "relay", hook.LastEntry().Data["relayHost"])
+
+		for i := 0; i < relayClockSkewSamples; i++ {
+			tracker.record("relay", 0)
+		}
+		require.Len(t, hook.AllEntries(), 2)
+		require.Equal(t, logrus.InfoLevel, hook.LastEntry().Level)
+	})
+
+	t.Run("measures the Date headers of relay responses", func(t *testing.T) {
+		var date atomic.Value
+		date.Store(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Date", date.Load().(string)) //nolint:forcetypeassert
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		relay, err := NewRelayEntry(fmt.Sprintf("http://%s@%s", types.PublicKey{0x01}.String(), ts.Listener.Addr().String()))
+		require.NoError(t, err)
+
+		logger, hook := logrustest.NewNullLogger()
+		tracker := newRelayClockTracker(http.DefaultTransport, []RelayEntry{relay}, 5*time.Second, logrus.NewEntry(logger))
+		client := http.Client{Transport: tracker}
+
+		resp, err := client.Get(relay.GetURI(pathStatus)) //nolint:noctx
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		skew, ok := tracker.median(relay.URL.Host)
+		require.True(t, ok)
+		require.InDelta(t, time.Minute, skew, float64(2*time.Second))
+		require.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+
+		// Malformed Date headers are ignored
+		date.Store("yesterday")
+		resp, err = client.Get(relay.GetURI(pathStatus)) //nolint:noctx
+		require.NoError(t, err)
+		resp.Body.Close()
+		skew, _ = tracker.median(relay.URL.Host)
+		require.InDelta(t, time.Minute, skew, float64(2*time.Second))
+	})
+}
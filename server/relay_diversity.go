@@ -0,0 +1,154 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	slotsPerEpoch = 32
+
+	relayWinsWindow = 24 * time.Hour // the rolling window of the win distribution
+
+	// relayWinShareMinWins is the number of wins in the window below which the win share is not checked, a relay
+	// winning the first few auctions says nothing about diversity
+	relayWinShareMinWins = 10
+)
+
+// relayWin is an auction won by a relay, i.e. a payload delivered by it
+type relayWin struct {
+	relay string
+	slot  uint64
+	time  time.Time
+}
+
+// relayWinDistribution is the number of auctions won per relay
+type relayWinDistribution struct {
+	numWins     int
+	winsByRelay map[string]int
+}
+
+// diversity returns the normalized entropy of the distribution: 1 if the wins are spread evenly across all relays,
+// 0 if a single relay won all auctions. With no wins, or a single relay, there is no diversity and 0 is returned.
+func (d relayWinDistribution) diversity(numRelays int) float64 {
+	if d.numWins == 0 || numRelays <= 1 {
+		return 0
+	}
+	entropy := 0.0
+	for _, wins := range d.winsByRelay {
+		p := float64(wins) / float64(d.numWins)
+		entropy -= p * math.Log(p)
+	}
+	return entropy / math.Log(float64(numRelays))
+}
+
+// top returns the relay with the most wins and its share of the wins, ties going to the relay which sorts first
+func (d relayWinDistribution) top() (relay string, share float64) {
+	maxWins := 0
+	for r, wins := range d.winsByRelay {
+		if wins > maxWins || (wins == maxWins && r < relay) {
+			relay, maxWins = r, wins
+		}
+	}
+	if d.numWins == 0 {
+		return "", 0
+	}
+	return relay, float64(maxWins) / float64(d.numWins)
+}
+
+// relayDiversityTracker keeps the relays which won the auctions within the rolling window, to report how the wins are
+// distributed per epoch and over the window. Epochs without proposals have no wins and are not reported.
+type relayDiversityTracker struct {
+	numRelays int
+	maxShare  float64 // warn when a relay's share of the wins in the window exceeds this, 0 disables the warning
+	log       *logrus.Entry
+
+	mu     sync.Mutex
+	wins   []relayWin // oldest first
+	warned bool       // whether a relay's share currently exceeds maxShare
+}
+
+func newRelayDiversityTracker(numRelays int, maxShare float64, log *logrus.Entry) *relayDiversityTracker {
+	return &relayDiversityTracker{
+		numRelays: numRelays,
+		maxShare:  maxShare,
+		log:       log,
+	}
+}
+
+// record adds an auction won by the relay, and returns the distributions of the slot's epoch and of the window. There
+// is one auction per slot, a payload delivered again for the slot, e.g. a retried getPayload, is not another win and
+// false is returned.
+func (t *relayDiversityTracker) record(relay string, slot uint64, now time.Time) (epoch, window relayWinDistribution, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	numExpired := 0
+	for numExpired < len(t.wins) && now.Sub(t.wins[numExpired].time) > relayWinsWindow {
+		numExpired++
+	}
+	t.wins = t.wins[numExpired:]
+	for _, win := range t.wins {
+		if win.slot == slot {
+			return epoch, window, false
+		}
+	}
+	t.wins = append(t.wins, relayWin{relay: relay, slot: slot, time: now})
+
+	epoch = relayWinDistribution{winsByRelay: make(map[string]int)}
+	window = relayWinDistribution{winsByRelay: make(map[string]int)}
+	for _, win := range t.wins {
+		window.numWins++
+		window.winsByRelay[win.relay]++
+		if win.slot/slotsPerEpoch == slot/slotsPerEpoch {
+			epoch.numWins++
+			epoch.winsByRelay[win.relay]++
+		}
+	}
+	return epoch, window, true
+}
+
+// recordWin adds an auction won by the relay, logs the distributions, and warns once when a single relay wins too
+// large a share of the auctions in the window
+func (t *relayDiversityTracker) recordWin(relay RelayEntry, slot uint64) {
+	epoch, window, ok := t.record(relay.String(), slot, time.Now())
+	if !ok {
+		t.log.WithFields(logrus.Fields{
+			"relay": relay.String(),
+			"slot":  slot,
+		}).Debug("payload delivered again for the slot, not counted as another win")
+		return
+	}
+	topRelay, topShare := window.top()
+
+	log := t.log.WithFields(logrus.Fields{
+		"relay":             relay.String(),
+		"epoch":             slot / slotsPerEpoch,
+		"epochWins":         epoch.winsByRelay,
+		"epochDiversity":    epoch.diversity(t.numRelays),
+		"windowWins":        window.winsByRelay,
+		"windowDiversity":   window.diversity(t.numRelays),
+		"windowTopRelay":    topRelay,
+		"windowTopRelayPct": math.Round(topShare * 100),
+	})
+	log.Info("relay win distribution")
+
+	if t.maxShare <= 0 || window.numWins < relayWinShareMinWins {
+		return
+	}
+	t.mu.Lock()
+	exceeded := topShare > t.maxShare
+	changed := exceeded != t.warned
+	t.warned = exceeded
+	t.mu.Unlock()
+
+	switch {
+	case changed && exceeded:
+		log.Warn("a single relay won too large a share of the auctions in the last 24h")
+	case changed:
+		log.Info("no single relay won too large a share of the auctions in the last 24h anymore")
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayWinDistribution(t *testing.T) {
+	t.Run("no wins", func(t *testing.T) {
+		d := relayWinDistribution{winsByRelay: map[string]int{}}
+		require.Equal(t, 0.0, d.diversity(3))
+		relay, share := d.top()
+		require.Equal(t, "", relay)
+		require.Equal(t, 0.0, share)
+	})
+
+	t.Run("single relay wins all", func(t *testing.T) {
+		d := relayWinDistribution{numWins: 4, winsByRelay: map[string]int{"a": 4}}
+		require.Equal(t, 0.0, d.diversity(3))
+		relay, share := d.top()
+		require.Equal(t, "a", relay)
+		require.Equal(t, 1.0, share)
+	})
+
+	t.Run("evenly spread", func(t *testing.T) {
+		d := relayWinDistribution{numWins: 6, winsByRelay: map[string]int{"a": 2, "b": 2, "c": 2}}
+		require.InDelta(t, 1.0, d.diversity(3), 1e-9)
+		require.InDelta(t, 0.79, d.diversity(4), 0.01) // one relay never won
+
+		relay, share := d.top()
+		require.Equal(t, "a", relay)
+		require.InDelta(t, 1.0/3, share, 1e-9)
+	})
+
+	t.Run("single configured relay", func(t *testing.T) {
+		d := relayWinDistribution{numWins: 1, winsByRelay: map[string]int{"a": 1}}
+		require.Equal(t, 0.0, d.diversity(1))
+	})
+}
+
+func TestRelayDiversityTracker(t *testing.T) {
+	tracker := newRelayDiversityTracker(2, 0.9, testLog)
+	now := time.Now()
+
+	epoch, window, ok := tracker.record("a", 10, now)
+	require.True(t, ok)
+	require.Equal(t, map[string]int{"a": 1}, epoch.winsByRelay)
+	require.Equal(t, 1, window.numWins)
+
+	// Same epoch, then a later one without any wins in between
+	epoch, _, _ = tracker.record("b", 20, now)
+	require.Equal(t, map[string]int{"a": 1, "b": 1}, epoch.winsByRelay)
+	epoch, window, _ = tracker.record("a", 20*slotsPerEpoch, now.Add(time.Hour))
+	require.Equal(t, map[string]int{"a": 1}, epoch.winsByRelay)
+	require.Equal(t, map[string]int{"a": 2, "b": 1}, window.winsByRelay)
+
+	// Wins older than the window are dropped
+	_, window, _ = tracker.record("b", 100*slotsPerEpoch, now.Add(relayWinsWindow+time.Minute))
+	require.Equal(t, map[string]int{"a": 1, "b": 1}, window.winsByRelay)
+
+	// A payload delivered again for a slot is not another win
+	_, _, ok = tracker.record("b", 100*slotsPerEpoch, now.Add(relayWinsWindow+2*time.Minute))
+	require.False(t, ok)
+	_, window, ok = tracker.record("a", 100*slotsPerEpoch+1, now.Add(relayWinsWindow+2*time.Minute))
+	require.True(t, ok)
+	require.Equal(t, map[string]int{"a": 2, "b": 1}, window.winsByRelay)
+}
+
+func TestRelayDiversityWarning(t *testing.T) {
+	relay, err := NewRelayEntry("https://0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249@relay1.example.com")
+	require.NoError(t, err)
+	tracker := newRelayDiversityTracker(2, 0.9, testLog)
+
+	for slot := uint64(0); slot < relayWinShareMinWins-1; slot++ {
+		tracker.recordWin(relay, slot)
+		require.False(t, tracker.warned)
+	}
+	tracker.recordWin(relay, relayWinShareMinWins)
+	require.True(t, tracker.warned)
+
+	// Disabled
+	tracker = newRelayDiversityTracker(2, 0, testLog)
+	for slot := uint64(0); slot < 2*relayWinShareMinWins; slot++ {
+		tracker.recordWin(relay, slot)
+	}
+	require.False(t, tracker.warned)
+}
@@ -2,7 +2,16 @@ package server
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/flashbots/go-boost-utils/types"
@@ -15,10 +24,54 @@ var pointAtInfinityPubkey = [48]byte{}
 type RelayEntry struct {
 	PublicKey types.PublicKey
 	URL       *url.URL
+
+	// ResolveAddr is an optional static address to connect to instead of resolving the relay's hostname.
+	// The hostname is still used for the Host header and TLS server name (like curl's --resolve).
+	ResolveAddr netip.AddrPort
+
+	// SPKIPins are optional hex encoded SHA-256 hashes of the SubjectPublicKeyInfo of the relay's TLS certificate.
+	// If set, the certificate must match one of them in addition to passing the normal verification.
+	SPKIPins []string
+
+	// Weight expresses a preference for the relay, the bid of the relay with the higher weight wins among bids of
+	// equal value. Relays without a weight have weight 0.
+	Weight int
+
+	// SchemeAssumed is set if the relay URL had no scheme, and http was assumed.
+	SchemeAssumed bool
+
+	// Headers are optional request headers sent to the relay, e.g. an API key. They can only be set with the JSON form
+	// of the relay entry, and are never part of String(), so their values don't end up in the logs.
+	Headers http.Header
+}
+
+// hasScheme returns whether the relay URL starts with a scheme, like https://. A "://" later in the URL, e.g. in the
+// query, is not a scheme.
+func hasScheme(relayURL string) bool {
+	scheme, _, found := strings.Cut(relayURL, "://")
+	if !found {
+		return false
+	}
+	u, err := url.Parse(scheme + ":")
+	return err == nil && u.Scheme != "" && strings.EqualFold(u.Scheme, scheme)
+}
+
+// relayEntryJSON is the JSON form of a relay entry, for relays which need request headers
+type relayEntryJSON struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
 }
 
+// String returns the relay URL, with the weight if it is set, so that it can be parsed again
 func (r *RelayEntry) String() string {
-	return r.URL.String()
+	if r.Weight == 0 {
+		return r.URL.String()
+	}
+	u := *r.URL
+	query := u.Query()
+	query.Set("weight", strconv.Itoa(r.Weight))
+	u.RawQuery = query.Encode()
+	return u.String()
 }
 
 // GetURI returns the full request URI with scheme, host, path and args for the relay.
@@ -27,19 +80,35 @@ func (r *RelayEntry) GetURI(path string) string {
 }
 
 // NewRelayEntry creates a new instance based on an input string
-// relayURL can be IP@PORT, PUBKEY@IP:PORT, https://IP, etc.
+// relayURL can be IP@PORT, PUBKEY@IP:PORT, https://IP, etc., or a JSON object with the URL and request headers.
 func NewRelayEntry(relayURL string) (entry RelayEntry, err error) {
+	// The JSON form carries request headers in addition to the URL: {"url": "...", "headers": {"X-Api-Key": "..."}}
+	if strings.HasPrefix(relayURL, "{") {
+		return newRelayEntryFromJSON(relayURL)
+	}
+
 	// Add protocol scheme prefix if it does not exist.
-	if !strings.HasPrefix(relayURL, "http") {
+	if !hasScheme(relayURL) {
 		relayURL = "http://" + relayURL
+		entry.SchemeAssumed = true
 	}
 
 	// Parse the provided relay's URL and save the parsed URL in the RelayEntry.
 	entry.URL, err = url.ParseRequestURI(relayURL)
 	if err != nil {
+		// The error repeats the input, which may contain credentials.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return entry, fmt.Errorf("invalid relay URL: %w", urlErr.Err)
+		}
 		return entry, err
 	}
 
+	// The userinfo is reserved for the public key, a password would end up in the logs.
+	if _, hasPassword := entry.URL.User.Password(); hasPassword {
+		return entry, ErrRelayURLPassword
+	}
+
 	// Extract the relay's public key from the parsed URL.
 	if entry.URL.User.Username() == "" {
 		return entry, ErrMissingRelayPubkey
@@ -56,9 +125,107 @@ func NewRelayEntry(relayURL string) (entry RelayEntry, err error) {
 		return entry, ErrPointAtInfinityPubkey
 	}
 
+	// Extract the static address to connect to, if any. It is not sent to the relay.
+	query := entry.URL.Query()
+	if resolve := query.Get("resolve"); resolve != "" {
+		entry.ResolveAddr, err = netip.ParseAddrPort(resolve)
+		if err != nil {
+			return entry, fmt.Errorf("%w: %s", ErrInvalidRelayResolveAddr, resolve)
+		}
+		query.Del("resolve")
+		entry.URL.RawQuery = query.Encode()
+	}
+
+	// Extract the certificate pins, if any. Like the resolve address, they are not sent to the relay.
+	if pins, ok := query["pin-sha256"]; ok {
+		if entry.URL.Scheme != "https" {
+			return entry, fmt.Errorf("%w: pins require an https relay URL", ErrInvalidRelaySPKIPin)
+		}
+		for _, pin := range pins {
+			hash, err := hex.DecodeString(strings.TrimPrefix(pin, "0x"))
+			if err != nil || len(hash) != sha256.Size {
+				return entry, fmt.Errorf("%w: %s", ErrInvalidRelaySPKIPin, pin)
+			}
+			entry.SPKIPins = append(entry.SPKIPins, hex.EncodeToString(hash))
+		}
+		query.Del("pin-sha256")
+		entry.URL.RawQuery = query.Encode()
+	}
+
+	// Extract the weight, if any. It is not sent to the relay either.
+	if weight := query.Get("weight"); weight != "" {
+		entry.Weight, err = strconv.Atoi(weight)
+		if err != nil || entry.Weight < 0 {
+			return entry, fmt.Errorf("%w: %s", ErrInvalidRelayWeight, weight)
+		}
+		query.Del("weight")
+		entry.URL.RawQuery = query.Encode()
+	}
+
+	return entry, nil
+}
+
+func newRelayEntryFromJSON(value string) (entry RelayEntry, err error) {
+	var v relayEntryJSON
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&v); err != nil {
+		return entry, fmt.Errorf("%w: %s", ErrInvalidRelayJSON, err.Error())
+	}
+	if strings.HasPrefix(v.URL, "{") {
+		return entry, fmt.Errorf("%w: url must be a relay URL", ErrInvalidRelayJSON)
+	}
+
+	entry, err = NewRelayEntry(v.URL)
+	if err != nil {
+		return entry, err
+	}
+	if len(v.Headers) == 0 {
+		return entry, nil
+	}
+
+	// Only the header names are in the errors, the values are secrets
+	entry.Headers = make(http.Header, len(v.Headers))
+	for name, value := range v.Headers {
+		if !isValidHeaderName(name) {
+			return entry, fmt.Errorf("%w: invalid name %q", ErrInvalidRelayHeader, name)
+		}
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return entry, fmt.Errorf("%w: invalid value for %s", ErrInvalidRelayHeader, name)
+		}
+		entry.Headers.Set(name, value)
+	}
 	return entry, nil
 }
 
+// isValidHeaderName returns whether the name is a valid HTTP header field name, see RFC 7230 section 3.2.6
+func isValidHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// hostPort returns the host and port the relay is reached at, using the default port of the scheme if none is set
+func (r *RelayEntry) hostPort() string {
+	port := r.URL.Port()
+	if port == "" {
+		port = "80"
+		if r.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(r.URL.Hostname(), port)
+}
+
 // RelayEntriesToStrings returns the string representation of a list of relay entries
 func RelayEntriesToStrings(relays []RelayEntry) []string {
 	ret := make([]string, len(relays))
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// RelayMonitorEntry represents a relay monitor that mev-boost sends data to. Like relays, relay monitors may publish
+// their public key as the username of the URL, but for relay monitors it is optional.
+type RelayMonitorEntry struct {
+	PublicKey types.PublicKey // the zero key if the URL has no public key, see HasPublicKey
+	URL       *url.URL
+}
+
+func (r *RelayMonitorEntry) String() string {
+	return r.URL.String()
+}
+
+// GetURI returns the full request URI with scheme, host, path and args for the relay monitor.
+func (r *RelayMonitorEntry) GetURI(path string) string {
+	return GetURI(r.URL, path)
+}
+
+// HasPublicKey returns whether the relay monitor URL has a public key
+func (r *RelayMonitorEntry) HasPublicKey() bool {
+	return r.PublicKey != types.PublicKey{}
+}
+
+// NewRelayMonitorEntry creates a new instance based on an input string. A username starting with 0x is parsed and
+// validated as the public key of the relay monitor, other usernames are kept in the URL as they are.
+func NewRelayMonitorEntry(relayMonitorURL string) (entry RelayMonitorEntry, err error) {
+	entry.URL, err = url.Parse(relayMonitorURL)
+	if err != nil {
+		// The error repeats the input, which may contain credentials.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return entry, fmt.Errorf("invalid relay monitor URL: %w", urlErr.Err)
+		}
+		return entry, err
+	}
+
+	if _, hasPassword := entry.URL.User.Password(); hasPassword {
+		return entry, ErrRelayMonitorURLPassword
+	}
+
+	username := entry.URL.User.Username()
+	if !strings.HasPrefix(username, "0x") && !strings.HasPrefix(username, "0X") {
+		return entry, nil
+	}
+	if err := entry.PublicKey.UnmarshalText([]byte(username)); err != nil {
+		return entry, fmt.Errorf("invalid relay monitor public key: %w", err)
+	}
+	if bytes.Equal(entry.PublicKey[:], pointAtInfinityPubkey[:]) {
+		return entry, ErrPointAtInfinityPubkey
+	}
+	return entry, nil
+}
+
+// RelayMonitorEntriesToStrings returns the string representation of a list of relay monitor entries
+func RelayMonitorEntriesToStrings(relayMonitors []RelayMonitorEntry) []string {
+	ret := make([]string, len(relayMonitors))
+	for i, entry := range relayMonitors {
+		ret[i] = entry.String()
+	}
+	return ret
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRelayMonitorURL(t *testing.T) {
+	publicKey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+
+	tests := []struct {
+		name              string
+		relayMonitorURL   string
+		expectedErr       error
+		expectedPublicKey string // empty if no public key is expected
+		expectedURI       string
+	}{
+		{
+			name:            "Without public key",
+			relayMonitorURL: "https://relay-monitor.example.com",
+			expectedURI:     "https://relay-monitor.example.com/eth/v1/builder/validators",
+		},
+		{
+			name:            "With username which is not a public key",
+			relayMonitorURL: "https://user@relay-monitor.example.com",
+			expectedURI:     "https://relay-monitor.example.com/eth/v1/builder/validators",
+		},
+		{
+			name:              "With public key",
+			relayMonitorURL:   "https://" + publicKey + "@relay-monitor.example.com",
+			expectedPublicKey: publicKey,
+			expectedURI:       "https://relay-monitor.example.com/eth/v1/builder/validators",
+		},
+		{
+			name:            "With public key of invalid length",
+			relayMonitorURL: "https://0x8a1d7b8dd64e@relay-monitor.example.com",
+			expectedErr:     types.ErrLength,
+		},
+		{
+			name:            "With point-at-infinity public key",
+			relayMonitorURL: "https://0x" + strings.Repeat("00", 48) + "@relay-monitor.example.com",
+			expectedErr:     ErrPointAtInfinityPubkey,
+		},
+		{
+			name:            "With password",
+			relayMonitorURL: "https://" + publicKey + ":secret@relay-monitor.example.com",
+			expectedErr:     ErrRelayMonitorURLPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relayMonitor, err := NewRelayMonitorEntry(tt.relayMonitorURL)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.relayMonitorURL, relayMonitor.String())
+			require.Equal(t, tt.expectedURI, relayMonitor.GetURI(pathRegisterValidator))
+			require.Equal(t, tt.expectedPublicKey != "", relayMonitor.HasPublicKey())
+			if tt.expectedPublicKey != "" {
+				require.Equal(t, tt.expectedPublicKey, relayMonitor.PublicKey.String())
+			}
+		})
+	}
+}
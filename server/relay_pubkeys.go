@@ -0,0 +1,46 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/go-boost-utils/types"
+)
+
+// relayPubkeys holds the parsed BLS public keys of the relays. Decoding a public key includes the curve and subgroup
+// checks, which would otherwise be repeated for every bid.
+type relayPubkeys map[types.PublicKey]*bls.PublicKey
+
+func newRelayPubkeys(relays []RelayEntry) (relayPubkeys, error) {
+	pubkeys := make(relayPubkeys, len(relays))
+	for _, relay := range relays {
+		if _, ok := pubkeys[relay.PublicKey]; ok {
+			continue
+		}
+		pk, err := bls.PublicKeyFromBytes(relay.PublicKey[:])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRelayPubkey, relay.PublicKey.String())
+		}
+		pubkeys[relay.PublicKey] = pk
+	}
+	return pubkeys, nil
+}
+
+// verifySignature verifies the signature of the message by the relay public key. Public keys which are not in the
+// cache are parsed on the fly.
+func (p relayPubkeys) verifySignature(msg types.HashTreeRoot, domain types.Domain, pubkey types.PublicKey, signature []byte) (bool, error) {
+	pk, ok := p[pubkey]
+	if !ok {
+		return types.VerifySignature(msg, domain, pubkey[:], signature)
+	}
+
+	root, err := types.ComputeSigningRoot(msg, domain)
+	if err != nil {
+		return false, err
+	}
+	sig, err := bls.SignatureFromBytes(signature)
+	if err != nil {
+		return false, err
+	}
+	return bls.VerifySignature(sig, pk, root[:])
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+// signedTestBid returns a bid signed by the mock relay, and the public key of the mock relay
+func signedTestBid(tb testing.TB) (*types.BuilderBid, []byte, types.PublicKey) {
+	tb.Helper()
+	var pubkey types.PublicKey
+	require.NoError(tb, pubkey.FromSlice(bls.PublicKeyToBytes(mockRelayPublicKey)))
+
+	bid := &types.BuilderBid{
+		Header: &types.ExecutionPayloadHeader{},
+		Value:  types.IntToU256(12345),
+		Pubkey: pubkey,
+	}
+	signature, err := types.SignMessage(bid, types.DomainBuilder, mockRelaySecretKey)
+	require.NoError(tb, err)
+	return bid, signature[:], pubkey
+}
+
+func TestRelayPubkeys(t *testing.T) {
+	bid, signature, pubkey := signedTestBid(t)
+
+	t.Run("verifies with the cached public key", func(t *testing.T) {
+		pubkeys, err := newRelayPubkeys([]RelayEntry{{PublicKey: pubkey}, {PublicKey: pubkey}})
+		require.NoError(t, err)
+		require.Len(t, pubkeys, 1)
+
+		ok, err := pubkeys.verifySignature(bid, types.DomainBuilder, pubkey, signature)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = pubkeys.verifySignature(bid, types.Domain{0x01}, pubkey, signature)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("parses public keys which are not cached", func(t *testing.T) {
+		pubkeys, err := newRelayPubkeys(nil)
+		require.NoError(t, err)
+
+		ok, err := pubkeys.verifySignature(bid, types.DomainBuilder, pubkey, signature)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("rejects invalid public keys", func(t *testing.T) {
+		_, err := newRelayPubkeys([]RelayEntry{{PublicKey: types.PublicKey{0x01}}})
+		require.ErrorIs(t, err, ErrInvalidRelayPubkey)
+	})
+}
+
+func BenchmarkVerifyRelaySignature(b *testing.B) {
+	bid, signature, pubkey := signedTestBid(b)
+
+	b.Run("parsed public key", func(b *testing.B) {
+		pubkeys, err := newRelayPubkeys([]RelayEntry{{PublicKey: pubkey}})
+		require.NoError(b, err)
+		for i := 0; i < b.N; i++ {
+			_, _ = pubkeys.verifySignature(bid, types.DomainBuilder, pubkey, signature)
+		}
+	})
+
+	b.Run("public key bytes", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = types.VerifySignature(bid, types.DomainBuilder, pubkey[:], signature)
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// newRelayTransport creates the HTTP transport shared by the relay clients. Connections to relays which have a
+// static resolve address are dialed to that address, while the Host header and TLS server name stay unchanged.
+// Relays with certificate pins must present a certificate matching one of them.
+func newRelayTransport(relays []RelayEntry) (*http.Transport, error) {
+	pinned := make(map[string]string)
+	spkiPins := make(map[string][]string) // by hostname, which is the TLS server name
+	for _, relay := range relays {
+		if len(relay.SPKIPins) > 0 {
+			host := relay.URL.Hostname()
+			if existing, ok := spkiPins[host]; ok && strings.Join(existing, ",") != strings.Join(relay.SPKIPins, ",") {
+				return nil, fmt.Errorf("%w: %s", ErrConflictingRelaySPKIPins, host)
+			}
+			spkiPins[host] = relay.SPKIPins
+		}
+
+		if !relay.ResolveAddr.IsValid() {
+			continue
+		}
+		hostPort := relay.hostPort()
+		addr := relay.ResolveAddr.String()
+		if existing, ok := pinned[hostPort]; ok && existing != addr {
+			return nil, fmt.Errorf("%w: %s (%s, %s)", ErrConflictingRelayResolveAddr, hostPort, existing, addr)
+		}
+		pinned[hostPort] = addr
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	if len(spkiPins) > 0 {
+		transport.TLSClientConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			// Called after the normal certificate verification, which is not replaced by the pins
+			VerifyConnection: func(cs tls.ConnectionState) error {
+				return verifySPKIPins(cs, spkiPins[cs.ServerName])
+			},
+		}
+	}
+	if len(pinned) == 0 {
+		return transport, nil
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if pinnedAddr, ok := pinned[addr]; ok {
+			addr = pinnedAddr
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return transport, nil
+}
+
+// verifySPKIPins checks the public key of the leaf certificate against the pins. The error names both the expected
+// and the observed hashes, so that a rotated certificate can be told apart from an attack.
+func verifySPKIPins(cs tls.ConnectionState, pins []string) error {
+	if len(pins) == 0 {
+		return nil
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("%w: %s presented no certificate", ErrRelaySPKIPinMismatch, cs.ServerName)
+	}
+
+	hash := sha256.Sum256(cs.PeerCertificates[0].RawSubjectPublicKeyInfo)
+	observed := hex.EncodeToString(hash[:])
+	for _, pin := range pins {
+		if pin == observed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s presented %s, expected one of %s", ErrRelaySPKIPinMismatch, cs.ServerName, observed, strings.Join(pins, ", "))
+}
+
+// relayHeaderTransport adds the request headers of the relays to all requests to them. Like the resolve addresses,
+// the headers are by relay host, so relays on the same host must have the same headers.
+type relayHeaderTransport struct {
+	next    http.RoundTripper
+	headers map[string]http.Header // by host, only relays with headers
+}
+
+func newRelayHeaderTransport(next http.RoundTripper, relays []RelayEntry) (http.RoundTripper, error) {
+	byHost := make(map[string]http.Header)
+	for _, relay := range relays {
+		host := relay.URL.Host
+		if existing, ok := byHost[host]; ok && !equalHeaders(existing, relay.Headers) {
+			return nil, fmt.Errorf("%w: %s", ErrConflictingRelayHeaders, host)
+		}
+		byHost[host] = relay.Headers
+	}
+
+	headers := make(map[string]http.Header)
+	for host, h := range byHost {
+		if len(h) > 0 {
+			headers[host] = h
+		}
+	}
+	if len(headers) == 0 {
+		return next, nil
+	}
+	return &relayHeaderTransport{next: next, headers: headers}, nil
+}
+
+func (t *relayHeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers, ok := t.headers[req.URL.Host]
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	return t.next.RoundTrip(req)
+}
+
+func equalHeaders(a, b http.Header) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, values := range a {
+		other, ok := b[name]
+		if !ok || strings.Join(values, "\n") != strings.Join(other, "\n") {
+			return false
+		}
+	}
+	return true
+}
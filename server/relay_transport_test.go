@@ -0,0 +1,152 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayTransport(t *testing.T) {
+	publicKey := types.PublicKey{0x01}
+
+	t.Run("connects to the pinned address with the relay's hostname", func(t *testing.T) {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "example.com", r.Host)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		tsURL, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+
+		// example.com is part of the test server's certificate, but does not resolve to it
+		relay, err := NewRelayEntry(fmt.Sprintf("https://%s@example.com?resolve=%s", publicKey.String(), tsURL.Host))
+		require.NoError(t, err)
+
+		transport, err := newRelayTransport([]RelayEntry{relay})
+		require.NoError(t, err)
+		transport.TLSClientConfig = ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone() //nolint:forcetypeassert
+
+		resp, err := (&http.Client{Transport: transport}).Get(relay.GetURI(pathStatus)) //nolint:noctx
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("verifies the certificate against the pins", func(t *testing.T) {
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		tsURL, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+		hash := sha256.Sum256(ts.Certificate().RawSubjectPublicKeyInfo)
+		validPin := hex.EncodeToString(hash[:])
+		otherPin := strings.Repeat("ab", 32)
+
+		get := func(pins ...string) (*http.Response, error) {
+			relay, err := NewRelayEntry(fmt.Sprintf("https://%s@example.com?resolve=%s&pin-sha256=%s", publicKey.String(), tsURL.Host, strings.Join(pins, "&pin-sha256=")))
+			require.NoError(t, err)
+			transport, err := newRelayTransport([]RelayEntry{relay})
+			require.NoError(t, err)
+
+			// Trust the test server's certificate, but keep the pin verification
+			tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone() //nolint:forcetypeassert
+			tlsConfig.VerifyConnection = transport.TLSClientConfig.VerifyConnection
+			transport.TLSClientConfig = tlsConfig
+			return (&http.Client{Transport: transport}).Get(relay.GetURI(pathStatus)) //nolint:noctx
+		}
+
+		resp, err := get(otherPin, validPin)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		_, err = get(otherPin) //nolint:bodyclose
+		require.ErrorIs(t, err, ErrRelaySPKIPinMismatch)
+		require.Contains(t, err.Error(), validPin)
+		require.Contains(t, err.Error(), otherPin)
+	})
+
+	t.Run("errors on conflicting pins", func(t *testing.T) {
+		relay1, err := NewRelayEntry(fmt.Sprintf("https://%s@example.com?pin-sha256=%s", publicKey.String(), strings.Repeat("ab", 32)))
+		require.NoError(t, err)
+		relay2, err := NewRelayEntry(fmt.Sprintf("https://%s@example.com:8443?pin-sha256=%s", publicKey.String(), strings.Repeat("cd", 32)))
+		require.NoError(t, err)
+
+		_, err = newRelayTransport([]RelayEntry{relay1, relay2})
+		require.ErrorIs(t, err, ErrConflictingRelaySPKIPins)
+	})
+
+	t.Run("errors on conflicting pinned addresses", func(t *testing.T) {
+		relay1, err := NewRelayEntry(fmt.Sprintf("https://%s@example.com?resolve=203.0.113.7:443", publicKey.String()))
+		require.NoError(t, err)
+		relay2, err := NewRelayEntry(fmt.Sprintf("https://%s@example.com?resolve=203.0.113.8:443", publicKey.String()))
+		require.NoError(t, err)
+
+		_, err = newRelayTransport([]RelayEntry{relay1, relay2})
+		require.ErrorIs(t, err, ErrConflictingRelayResolveAddr)
+	})
+}
+
+func TestRelayHeaderTransport(t *testing.T) {
+	publicKey := types.PublicKey{0x01}
+
+	t.Run("sends the headers to that relay only", func(t *testing.T) {
+		received := make(chan string, 2)
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- r.Host + " " + r.Header.Get("X-Api-Key")
+			w.WriteHeader(http.StatusOK)
+		})
+		ts1 := httptest.NewServer(handler)
+		defer ts1.Close()
+		ts2 := httptest.NewServer(handler)
+		defer ts2.Close()
+
+		relay1, err := NewRelayEntry(fmt.Sprintf(`{"url": "%s@%s", "headers": {"X-Api-Key": "key1"}}`, publicKey.String(), strings.TrimPrefix(ts1.URL, "http://")))
+		require.NoError(t, err)
+		relay2, err := NewRelayEntry(fmt.Sprintf("%s@%s", publicKey.String(), strings.TrimPrefix(ts2.URL, "http://")))
+		require.NoError(t, err)
+
+		transport, err := newRelayHeaderTransport(http.DefaultTransport, []RelayEntry{relay1, relay2})
+		require.NoError(t, err)
+		client := &http.Client{Transport: transport}
+
+		for _, relay := range []RelayEntry{relay1, relay2} {
+			req, err := http.NewRequest(http.MethodGet, relay.GetURI(pathStatus), nil) //nolint:noctx
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Empty(t, req.Header.Get("X-Api-Key"), "the request must not be modified")
+		}
+		require.Equal(t, relay1.URL.Host+" key1", <-received)
+		require.Equal(t, relay2.URL.Host+" ", <-received)
+	})
+
+	t.Run("no relay with headers", func(t *testing.T) {
+		relay, err := NewRelayEntry(fmt.Sprintf("https://%s@example.com", publicKey.String()))
+		require.NoError(t, err)
+		transport, err := newRelayHeaderTransport(http.DefaultTransport, []RelayEntry{relay})
+		require.NoError(t, err)
+		require.Equal(t, http.DefaultTransport, transport)
+	})
+
+	t.Run("rejects different headers for the same host", func(t *testing.T) {
+		relay1, err := NewRelayEntry(fmt.Sprintf(`{"url": "https://%s@example.com/a", "headers": {"X-Api-Key": "key1"}}`, publicKey.String()))
+		require.NoError(t, err)
+		relay2, err := NewRelayEntry(fmt.Sprintf("https://%s@example.com/b", publicKey.String()))
+		require.NoError(t, err)
+		_, err = newRelayHeaderTransport(http.DefaultTransport, []RelayEntry{relay1, relay2})
+		require.ErrorIs(t, err, ErrConflictingRelayHeaders)
+	})
+}
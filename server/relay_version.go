@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type relayServerVersion struct {
+	server   string
+	observed time.Time
+}
+
+// relayVersionTracker records the Server header of the most recent response of every relay, which the status
+// endpoint reports, and logs when it changes. A changed Server header usually means that the relay deployed a new
+// version.
+type relayVersionTracker struct {
+	next     http.RoundTripper
+	log      *logrus.Entry
+	relays   map[string]bool // hosts of the relays, a relay monitor's Server header is not a relay version
+	mu       sync.Mutex
+	versions map[string]relayServerVersion
+}
+
+func newRelayVersionTracker(next http.RoundTripper, relays []RelayEntry, log *logrus.Entry) *relayVersionTracker {
+	hosts := make(map[string]bool, len(relays))
+	for _, relay := range relays {
+		hosts[relay.URL.Host] = true
+	}
+	return &relayVersionTracker{
+		next:     next,
+		log:      log,
+		relays:   hosts,
+		versions: make(map[string]relayServerVersion),
+	}
+}
+
+func (t *relayVersionTracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && t.relays[req.URL.Host] {
+		if server := resp.Header.Get("Server"); server != "" {
+			t.record(req.URL.Host, server, time.Now())
+		}
+	}
+	return resp, err
+}
+
+func (t *relayVersionTracker) record(host, server string, now time.Time) {
+	t.mu.Lock()
+	previous, known := t.versions[host]
+	t.versions[host] = relayServerVersion{server: server, observed: now}
+	t.mu.Unlock()
+
+	log := t.log.WithFields(logrus.Fields{
+		"relayHost": host,
+		"server":    server,
+	})
+	if !known {
+		log.Info("relay server identified")
+	} else if previous.server != server {
+		log.WithFields(logrus.Fields{
+			"previousServer":   previous.server,
+			"previousObserved": previous.observed.UTC().Format(time.RFC3339),
+		}).Info("relay server changed, the relay probably deployed a new version")
+	}
+}
+
+// get returns the most recent server identification of the relay host, and when it was observed
+func (t *relayVersionTracker) get(host string) (server string, observed time.Time, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	version, ok := t.versions[host]
+	return version.server, version.observed, ok
+}
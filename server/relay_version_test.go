@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/flashbots/go-boost-utils/types"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayVersionTracker(t *testing.T) {
+	var server atomic.Value
+	server.Store("relay/v0.1.0")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", server.Load().(string)) //nolint:forcetypeassert
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	monitor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "monitor/v1")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer monitor.Close()
+
+	relay, err := NewRelayEntry(fmt.Sprintf("http://%s@%s", types.PublicKey{0x01}.String(), ts.Listener.Addr().String()))
+	require.NoError(t, err)
+
+	logger, hook := logrustest.NewNullLogger()
+	tracker := newRelayVersionTracker(http.DefaultTransport, []RelayEntry{relay}, logrus.NewEntry(logger))
+	client := http.Client{Transport: tracker}
+	get := func(url string) {
+		t.Helper()
+		resp, err := client.Get(url) //nolint:noctx
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	_, _, ok := tracker.get(relay.URL.Host)
+	require.False(t, ok)
+
+	get(relay.GetURI(pathStatus))
+	observed, _, ok := tracker.get(relay.URL.Host)
+	require.True(t, ok)
+	require.Equal(t, "relay/v0.1.0", observed)
+	require.Equal(t, "relay server identified", hook.LastEntry().Message)
+
+	// An unchanged server is not logged again
+	get(relay.GetURI(pathStatus))
+	require.Len(t, hook.AllEntries(), 1)
+
+	server.Store("relay/v0.2.0")
+	get(relay.GetURI(pathStatus))
+	observed, _, _ = tracker.get(relay.URL.Host)
+	require.Equal(t, "relay/v0.2.0", observed)
+	require.Len(t, hook.AllEntries(), 2)
+	require.Equal(t, "relay/v0.1.0", hook.LastEntry().Data["previousServer"])
+	require.Equal(t, "relay/v0.2.0", hook.LastEntry().Data["server"])
+
+	// Hosts which are not relays are ignored
+	get(monitor.URL)
+	_, _, ok = tracker.get(monitor.Listener.Addr().String())
+	require.False(t, ok)
+	require.Len(t, hook.AllEntries(), 2)
+}
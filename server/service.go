@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"net/http"
-	"net/url"
+	"net/netip"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,6 +19,7 @@ import (
 
 	"github.com/attestantio/go-builder-client/api"
 	"github.com/attestantio/go-eth2-client/api/v1/capella"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/flashbots/go-boost-utils/types"
 	"github.com/flashbots/go-utils/httplogger"
 	"github.com/flashbots/mev-boost/config"
@@ -25,12 +28,18 @@ import (
 )
 
 var (
-	errNoRelays                  = errors.New("no relays")
-	errInvalidSlot               = errors.New("invalid slot")
-	errInvalidHash               = errors.New("invalid hash")
-	errInvalidPubkey             = errors.New("invalid pubkey")
-	errNoSuccessfulRelayResponse = errors.New("no successful relay response")
-	errServerAlreadyRunning      = errors.New("server already running")
+	errNoRelays                   = errors.New("no relays")
+	errInvalidSlot                = errors.New("invalid slot")
+	errInvalidHash                = errors.New("invalid hash")
+	errInvalidPubkey              = errors.New("invalid pubkey")
+	errNoSuccessfulRelayResponse  = errors.New("no successful relay response")
+	errServerAlreadyRunning       = errors.New("server already running")
+	errGetHeaderDisabled          = errors.New("getHeader is disabled")
+	errGetPayloadDisabled         = errors.New("getPayload is disabled")
+	errGetPayloadWithoutHeader    = errors.New("getPayload cannot be enabled while getHeader is disabled")
+	errPastSlot                   = errors.New("slot is in the past")
+	errClientNotAllowed           = errors.New("client address is not allowed")
+	errTooManyRegistrationBatches = errors.New("too many validator registration batches in flight")
 )
 
 var (
@@ -49,12 +58,23 @@ type AuctionTranscript struct {
 	Acceptance *types.SignedBlindedBeaconBlock `json:"acceptance"`
 }
 
+// TopBidObserved is the highest bid received for a slot, sent to the relay monitors which opted in to receive it.
+// It intentionally carries no proposer identity.
+type TopBidObserved struct {
+	Slot         uint64   `json:"slot,string"`
+	ParentHash   string   `json:"parent_hash"`
+	BlockHash    string   `json:"block_hash"`
+	Value        string   `json:"value"`
+	RelayPubkeys []string `json:"relay_pubkeys"`
+}
+
 // BoostServiceOpts provides all available options for use with NewBoostService
 type BoostServiceOpts struct {
 	Log                   *logrus.Entry
 	ListenAddr            string
 	Relays                []RelayEntry
-	RelayMonitors         []*url.URL
+	RelayMonitors         []RelayMonitorEntry
+	RelayMonitorsTopBid   []RelayMonitorEntry // relay monitors receiving the top bid of every slot, must be part of RelayMonitors
 	GenesisForkVersionHex string
 	RelayCheck            bool
 	RelayMinBid           types.U256Str
@@ -63,26 +83,97 @@ type BoostServiceOpts struct {
 	RequestTimeoutGetPayload time.Duration
 	RequestTimeoutRegVal     time.Duration
 	RequestMaxRetries        int
+
+	RelayMonitorRegistrationHeartbeat time.Duration
+
+	DisableGetHeader  bool
+	DisableGetPayload bool
+
+	GenesisTime            uint64 // unix timestamp of the beacon chain genesis, 0 disables the getHeader slot checks
+	GetHeaderSlotTolerance uint64 // number of slots a getHeader request may be behind or ahead of the current slot
+	StrictGetHeaderSlot    bool   // respond to getHeader requests for past slots with 400 instead of 204
+	NoBidReasonHeader      bool   // add the reason to 204 getHeader responses, in the X-MEV-Boost-No-Bid-Reason header
+
+	LogThrottleWindow time.Duration // identical relay and relay monitor warnings are logged at most once per window
+
+	MaxClockSkew time.Duration // warn when the rolling median clock skew of a relay exceeds this, 0 disables the warning
+
+	CORSAllowedOrigins []string // origins which may call the API from a browser, "*" allows all
+
+	APIAllowedCIDRs []netip.Prefix // if set, only clients within these prefixes may call the API
+
+	RelayBreakerFailures int           // skip a relay for getHeader after this many failed requests in a row, 0 disables
+	RelayBreakerCooldown time.Duration // how long a relay is skipped before it is probed again
+
+	APIGzip          bool // compress large responses for clients which accept gzip
+	APIGzipGetHeader bool // also compress getHeader responses, requires APIGzip
+
+	RegistrationMaxInFlight int // registerValidator batches processed at the same time, more are rejected with 503, 0 allows any number
+
+	RelayWinShareWarn float64 // warn when a relay wins more than this share of the auctions in 24h, 0 disables
 }
 
 // BoostService - the mev-boost service
 type BoostService struct {
 	listenAddr    string
 	relays        []RelayEntry
-	relayMonitors []*url.URL
+	relayMonitors []RelayMonitorEntry
 	log           *logrus.Entry
 	srv           *http.Server
 	relayCheck    bool
 	relayMinBid   types.U256Str
 
+	relayMonitorsTopBid []RelayMonitorEntry
+	topBids             *topBidTracker // the top bid of the running slots, sent to the relay monitors once a slot is over
+
+	disableGetHeader  bool
+	disableGetPayload bool
+
+	slotClock               slotClock
+	getHeaderSlotTolerance  uint64
+	strictGetHeaderSlot     bool
+	noBidReasonHeader       bool
+	numPastSlotRequests     uint64 // getHeader requests for past slots, updated atomically
+	numFutureSlotRequests   uint64 // getHeader requests for far future slots, updated atomically
+	numPubkeyNormalizations uint64 // getHeader requests with a non-canonical proposer pubkey, updated atomically
+
 	builderSigningDomain types.Domain
+	relayPubkeys         relayPubkeys // the parsed relay public keys, to verify the bid signatures
 	httpClientGetHeader  http.Client
 	httpClientGetPayload http.Client
 	httpClientRegVal     http.Client
 	requestMaxRetries    int
 
-	bidsLock sync.Mutex
-	bids     map[bidRespKey]bidResp // keeping track of bids, to log the originating relay on withholding
+	bids *bidCache // keeping track of bids, to log the originating relay on withholding
+
+	monitorRegistrations *registrationDeduplicator // avoids forwarding unchanged registrations to the relay monitors
+	registrationRates    *registrationRateTracker  // finds validator clients which register more often than once per epoch
+
+	logThrottle *logThrottler // keeps failing relays and relay monitors from flooding the logs
+
+	payloadValues         *payloadValueTracker   // compares the bid values with the payments in the delivered payloads
+	proposerFeeRecipients *proposerFeeRecipients // the registered fee recipients, to find the payments to the proposers
+
+	relayVersions *relayVersionTracker // the server identification of the relays, from their response headers
+	relayClocks   *relayClockTracker   // the clock skew of the relays, from their response headers
+
+	relayBreaker *relayBreaker // skips relays which keep failing getHeader requests
+
+	numEmptyBidResponses *relayCounter // getHeader responses with status 200 but without a bid, per relay
+
+	lastSuccess *lastSuccessTracker // the last success of each proposal step, reported on the status endpoint
+
+	corsAllowedOrigins []string
+
+	apiAllowedCIDRs    []netip.Prefix
+	numRejectedClients uint64 // requests rejected because of the client address, updated atomically
+
+	apiGzip          bool
+	apiGzipGetHeader bool
+
+	registrationBatches *registrationBatches // limits the registerValidator batches in flight
+
+	relayDiversity *relayDiversityTracker // how the won auctions are distributed across the relays
 }
 
 // NewBoostService created a new BoostService
@@ -91,37 +182,113 @@ func NewBoostService(opts BoostServiceOpts) (*BoostService, error) {
 		return nil, errNoRelays
 	}
 
+	if opts.DisableGetHeader && !opts.DisableGetPayload {
+		return nil, errGetPayloadWithoutHeader
+	}
+
 	builderSigningDomain, err := ComputeDomain(types.DomainTypeAppBuilder, opts.GenesisForkVersionHex, types.Root{}.String())
 	if err != nil {
 		return nil, err
 	}
 
-	return &BoostService{
+	relayPubkeys, err := newRelayPubkeys(opts.Relays)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := newRelayTransport(opts.Relays)
+	if err != nil {
+		return nil, err
+	}
+	headerTransport, err := newRelayHeaderTransport(transport, opts.Relays)
+	if err != nil {
+		return nil, err
+	}
+	relayClocks := newRelayClockTracker(headerTransport, opts.Relays, opts.MaxClockSkew, opts.Log)
+	relayVersions := newRelayVersionTracker(relayClocks, opts.Relays, opts.Log)
+
+	m := &BoostService{
 		listenAddr:    opts.ListenAddr,
 		relays:        opts.Relays,
 		relayMonitors: opts.RelayMonitors,
 		log:           opts.Log,
 		relayCheck:    opts.RelayCheck,
 		relayMinBid:   opts.RelayMinBid,
-		bids:          make(map[bidRespKey]bidResp),
+		bids:          newBidCache(bidCacheKeepSlots, bidCacheMaxEntries),
+
+		relayMonitorsTopBid: opts.RelayMonitorsTopBid,
+		topBids:             newTopBidTracker(),
+
+		disableGetHeader:  opts.DisableGetHeader,
+		disableGetPayload: opts.DisableGetPayload,
+
+		slotClock:              slotClock{genesisTime: opts.GenesisTime},
+		getHeaderSlotTolerance: opts.GetHeaderSlotTolerance,
+		strictGetHeaderSlot:    opts.StrictGetHeaderSlot,
+		noBidReasonHeader:      opts.NoBidReasonHeader,
 
 		builderSigningDomain: builderSigningDomain,
+		relayPubkeys:         relayPubkeys,
 		httpClientGetHeader: http.Client{
+			Transport:     relayVersions,
 			Timeout:       opts.RequestTimeoutGetHeader,
 			CheckRedirect: httpClientDisallowRedirects,
 		},
 		httpClientGetPayload: http.Client{
+			Transport:     relayVersions,
 			Timeout:       opts.RequestTimeoutGetPayload,
 			CheckRedirect: httpClientDisallowRedirects,
 		},
 		httpClientRegVal: http.Client{
+			Transport:     relayVersions,
 			Timeout:       opts.RequestTimeoutRegVal,
 			CheckRedirect: httpClientDisallowRedirects,
 		},
 		requestMaxRetries: opts.RequestMaxRetries,
-	}, nil
+
+		monitorRegistrations: newRegistrationDeduplicator(opts.RelayMonitorRegistrationHeartbeat),
+		registrationRates:    newRegistrationRateTracker(),
+
+		logThrottle: newLogThrottler(opts.LogThrottleWindow, logThrottleMaxEntries),
+
+		payloadValues:         newPayloadValueTracker(),
+		proposerFeeRecipients: newProposerFeeRecipients(),
+
+		relayVersions: relayVersions,
+		relayClocks:   relayClocks,
+
+		relayBreaker: newRelayBreaker(opts.RelayBreakerFailures, opts.RelayBreakerCooldown, opts.Log),
+
+		numEmptyBidResponses: newRelayCounter(),
+
+		lastSuccess: newLastSuccessTracker(time.Now()),
+
+		corsAllowedOrigins: opts.CORSAllowedOrigins,
+
+		apiAllowedCIDRs: opts.APIAllowedCIDRs,
+
+		apiGzip:          opts.APIGzip,
+		apiGzipGetHeader: opts.APIGzipGetHeader,
+
+		registrationBatches: newRegistrationBatches(opts.RegistrationMaxInFlight),
+
+		relayDiversity: newRelayDiversityTracker(len(opts.Relays), opts.RelayWinShareWarn, opts.Log),
+	}
+	m.bids.maxSlot = m.maxBidSlot
+	return m, nil
+}
+
+// maxBidSlot returns the highest slot for which bids are expected. Later slots are the far future slots getHeader
+// warns about, which come from beacon nodes with a wrong clock or genesis time.
+func (m *BoostService) maxBidSlot() uint64 {
+	if !m.slotClock.known() {
+		return math.MaxUint64
+	}
+	return m.slotClock.currentSlot(time.Now()) + m.getHeaderSlotTolerance
 }
 
+// respondError sends an error to the beacon node. The message must not contain relay URLs or relay responses, which
+// may carry credentials; those details are only logged.
 func (m *BoostService) respondError(w http.ResponseWriter, code int, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -132,6 +299,14 @@ func (m *BoostService) respondError(w http.ResponseWriter, code int, message str
 	}
 }
 
+// respondNoBid answers a getHeader request without a bid, with the reason if enabled
+func (m *BoostService) respondNoBid(w http.ResponseWriter, summary *auctionSummary) {
+	if m.noBidReasonHeader {
+		w.Header().Set(headerNoBidReason, summary.noBidReason())
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (m *BoostService) respondOK(w http.ResponseWriter, response any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -145,13 +320,27 @@ func (m *BoostService) getRouter() http.Handler {
 	r := mux.NewRouter()
 	r.HandleFunc("/", m.handleRoot)
 
-	r.HandleFunc(pathStatus, m.handleStatus).Methods(http.MethodGet)
-	r.HandleFunc(pathRegisterValidator, m.handleRegisterValidator).Methods(http.MethodPost)
-	r.HandleFunc(pathGetHeader, m.handleGetHeader).Methods(http.MethodGet)
-	r.HandleFunc(pathGetPayload, m.handleGetPayload).Methods(http.MethodPost)
+	r.HandleFunc(pathStatus, m.handleStatus).Methods(http.MethodGet, http.MethodHead, http.MethodOptions)
+	r.HandleFunc(pathRegisterValidator, m.handleRegisterValidator).Methods(http.MethodPost, http.MethodOptions)
+	r.HandleFunc(pathGetHeader, m.handleGetHeader).Methods(http.MethodGet, http.MethodOptions)
+	r.HandleFunc(pathGetPayload, m.handleGetPayload).Methods(http.MethodPost, http.MethodOptions)
 
 	r.Use(mux.CORSMethodMiddleware(r))
-	loggedRouter := httplogger.LoggingMiddlewareLogrus(m.log, r)
+	if len(m.corsAllowedOrigins) > 0 {
+		r.Use(corsMiddleware(m.corsAllowedOrigins))
+	}
+	r.Use(optionsMiddleware)
+	r.Use(headMiddleware)
+	if m.apiGzip {
+		r.Use(gzipMiddleware(m.apiGzipGetHeader))
+	}
+
+	// The client address is checked for all requests, not only the matched routes
+	var handler http.Handler = r
+	if len(m.apiAllowedCIDRs) > 0 {
+		handler = m.allowedCIDRsMiddleware(handler)
+	}
+	loggedRouter := httplogger.LoggingMiddlewareLogrus(m.log, handler)
 	return loggedRouter
 }
 
@@ -185,25 +374,38 @@ func (m *BoostService) StartHTTPServer() error {
 func (m *BoostService) startBidCacheCleanupTask() {
 	for {
 		time.Sleep(1 * time.Minute)
-		m.bidsLock.Lock()
-		for k, bidResp := range m.bids {
-			if time.Since(bidResp.t) > 3*time.Minute {
-				delete(m.bids, k)
-			}
-		}
-		m.bidsLock.Unlock()
+		m.bids.pruneOlderThan(3 * time.Minute)
+		m.log.WithField("numBids", m.bids.len()).Debug("cleaned up bid cache")
 	}
 }
 
 func (m *BoostService) sendValidatorRegistrationsToRelayMonitors(payload []types.SignedValidatorRegistration) {
-	log := m.log.WithField("method", "sendValidatorRegistrationsToRelayMonitors").WithField("numRegistrations", len(payload))
+	if len(m.relayMonitors) == 0 {
+		return
+	}
+
+	numRegistrations := len(payload)
+	payload = m.monitorRegistrations.filter(payload)
+	numForwarded, numSuppressed := m.monitorRegistrations.counts()
+	log := m.log.WithFields(logrus.Fields{
+		"method":           "sendValidatorRegistrationsToRelayMonitors",
+		"numRegistrations": numRegistrations,
+		"numChanged":       len(payload),
+		"totalForwarded":   numForwarded,
+		"totalSuppressed":  numSuppressed,
+	})
+	if len(payload) == 0 {
+		log.Debug("no changed validator registrations to send to relay monitors")
+		return
+	}
+
 	for _, relayMonitor := range m.relayMonitors {
-		go func(relayMonitor *url.URL) {
-			url := GetURI(relayMonitor, pathRegisterValidator)
-			log = log.WithField("url", url)
+		go func(relayMonitor RelayMonitorEntry) {
+			url := relayMonitor.GetURI(pathRegisterValidator)
+			log := log.WithField("url", url)
 			_, err := SendHTTPRequest(context.Background(), m.httpClientRegVal, http.MethodPost, url, "", payload, nil)
 			if err != nil {
-				log.WithError(err).Warn("error calling registerValidator on relay monitor")
+				m.logThrottle.warn(log.WithError(err), "error calling registerValidator on relay monitor", relayMonitor.String())
 				return
 			}
 			log.Debug("sent validator registrations to relay monitor")
@@ -214,12 +416,12 @@ func (m *BoostService) sendValidatorRegistrationsToRelayMonitors(payload []types
 func (m *BoostService) sendAuctionTranscriptToRelayMonitors(transcript *AuctionTranscript) {
 	log := m.log.WithField("method", "sendAuctionTranscriptToRelayMonitors")
 	for _, relayMonitor := range m.relayMonitors {
-		go func(relayMonitor *url.URL) {
-			url := GetURI(relayMonitor, pathAuctionTranscript)
+		go func(relayMonitor RelayMonitorEntry) {
+			url := relayMonitor.GetURI(pathAuctionTranscript)
 			log := log.WithField("url", url)
 			_, err := SendHTTPRequest(context.Background(), *http.DefaultClient, http.MethodPost, url, UserAgent(""), transcript, nil)
 			if err != nil {
-				log.WithError(err).Warn("error sending auction transcript to relay monitor")
+				m.logThrottle.warn(log.WithError(err), "error sending auction transcript to relay monitor", relayMonitor.String())
 				return
 			}
 			log.Debug("sent auction transcript to relay monitor")
@@ -227,60 +429,179 @@ func (m *BoostService) sendAuctionTranscriptToRelayMonitors(transcript *AuctionT
 	}
 }
 
+// observeTopBid records the best bid of a getHeader request. The highest bid of the slot is sent to the opted-in relay
+// monitors once the slot is over, or, if the genesis time is unknown, one slot after its first bid.
+func (m *BoostService) observeTopBid(bid *TopBidObserved, value *big.Int) {
+	// The top bid of a far future slot would keep a timer and an entry around until that slot is over
+	if len(m.relayMonitorsTopBid) == 0 || bid.Slot > m.maxBidSlot() || !m.topBids.observe(bid, value) {
+		return
+	}
+
+	delay := secondsPerSlot * time.Second
+	if m.slotClock.known() {
+		delay = time.Until(m.slotClock.slotStart(bid.Slot + 1))
+	}
+	time.AfterFunc(delay, func() {
+		if topBid := m.topBids.take(bid.Slot); topBid != nil {
+			m.sendTopBidToRelayMonitors(topBid)
+		}
+	})
+}
+
+// sendTopBidToRelayMonitors sends the top bid observed for a slot to the opted-in relay monitors
+func (m *BoostService) sendTopBidToRelayMonitors(topBid *TopBidObserved) {
+	log := m.log.WithFields(logrus.Fields{
+		"method": "sendTopBidToRelayMonitors",
+		"slot":   topBid.Slot,
+	})
+	for _, relayMonitor := range m.relayMonitorsTopBid {
+		go func(relayMonitor RelayMonitorEntry) {
+			url := relayMonitor.GetURI(pathTopBid)
+			log := log.WithField("url", url)
+			_, err := SendHTTPRequest(context.Background(), m.httpClientRegVal, http.MethodPost, url, UserAgent(""), topBid, nil)
+			if err != nil {
+				m.logThrottle.warn(log.WithError(err), "error sending top bid to relay monitor", relayMonitor.String())
+				return
+			}
+			log.Debug("sent top bid to relay monitor")
+		}(relayMonitor)
+	}
+}
+
 func (m *BoostService) handleRoot(w http.ResponseWriter, req *http.Request) {
 	m.respondOK(w, nilResponse)
 }
 
 // handleStatus sends calls to the status endpoint of every relay.
 // It returns OK if at least one returned OK, and returns error otherwise.
+// The response headers report what mev-boost observed of the relays, per relay host.
 func (m *BoostService) handleStatus(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("X-MEVBoost-Version", config.Version)
 	w.Header().Set("X-MEVBoost-ForkVersion", config.ForkVersion)
-	if !m.relayCheck || m.CheckRelays() > 0 {
+	if disabled := m.disabledMethods(); len(disabled) > 0 {
+		w.Header().Set("X-MEVBoost-Disabled", strings.Join(disabled, ","))
+	}
+
+	available := !m.relayCheck || m.CheckRelays() > 0
+	m.setRelayStatusHeader(w, "X-MEVBoost-Relay-Clock-Skew", func(host string) (string, bool) {
+		skew, ok := m.relayClocks.median(host)
+		return skew.String(), ok
+	})
+	m.setRelayStatusHeader(w, "X-MEVBoost-Relay-Server", func(host string) (string, bool) {
+		server, _, ok := m.relayVersions.get(host)
+		return strconv.Quote(server), ok
+	})
+	w.Header().Set("X-MEVBoost-Last-Success", m.lastSuccess.String())
+
+	if available {
 		m.respondOK(w, nilResponse)
 	} else {
 		m.respondError(w, http.StatusServiceUnavailable, "all relays are unavailable")
 	}
 }
 
+// setRelayStatusHeader sets the header to the value of every relay host which has one, e.g. "relay1.example.com=12ms,
+// relay2.example.com=-3ms", in the order of the relays. The header is not set if no relay has a value.
+func (m *BoostService) setRelayStatusHeader(w http.ResponseWriter, header string, value func(host string) (string, bool)) {
+	values := make([]string, 0, len(m.relays))
+	for _, relay := range m.relays {
+		if v, ok := value(relay.URL.Host); ok {
+			values = append(values, relay.URL.Host+"="+v)
+		}
+	}
+	if len(values) > 0 {
+		w.Header().Set(header, strings.Join(values, ", "))
+	}
+}
+
+// disabledMethods returns the builder API methods which are disabled, and answered with 501
+func (m *BoostService) disabledMethods() []string {
+	var disabled []string
+	if m.disableGetHeader {
+		disabled = append(disabled, "getHeader")
+	}
+	if m.disableGetPayload {
+		disabled = append(disabled, "getPayload")
+	}
+	return disabled
+}
+
 // handleRegisterValidator - returns 200 if at least one relay returns 200, else 502
 func (m *BoostService) handleRegisterValidator(w http.ResponseWriter, req *http.Request) {
 	log := m.log.WithField("method", "registerValidator")
 	log.Debug("registerValidator")
 
+	// Large batches hold a lot of memory until all relays responded, so only a limited number is processed at once
+	if !m.registrationBatches.acquire() {
+		numInFlight, bufferedBytes := m.registrationBatches.stats()
+		log.WithFields(logrus.Fields{
+			"inFlightBatches": numInFlight,
+			"bufferedBytes":   bufferedBytes,
+		}).Warn("too many validator registration batches in flight, rejecting the request")
+		w.Header().Set("Retry-After", strconv.Itoa(m.registrationRetryAfterSec()))
+		m.respondError(w, http.StatusServiceUnavailable, errTooManyRegistrationBatches.Error())
+		return
+	}
+
 	payload := []types.SignedValidatorRegistration{}
 	if err := DecodeJSON(req.Body, &payload); err != nil {
+		m.registrationBatches.release(0)
 		m.respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	m.proposerFeeRecipients.record(payload)
+	m.checkRegistrationRate(log, req, payload)
+
+	// The registrations are encoded once for all relays, instead of once per relay
+	body, err := encodeRegistrations(payload)
+	if err != nil {
+		m.registrationBatches.release(0)
+		log.WithError(err).Error("could not encode validator registrations")
+		m.respondError(w, http.StatusInternalServerError, "could not encode validator registrations")
+		return
+	}
+	m.registrationBatches.buffered(len(body))
+	numInFlight, bufferedBytes := m.registrationBatches.stats()
 
 	ua := UserAgent(req.Header.Get("User-Agent"))
 	log = log.WithFields(logrus.Fields{
 		"numRegistrations": len(payload),
 		"ua":               ua,
+		"inFlightBatches":  numInFlight,
+		"bufferedBytes":    bufferedBytes,
 	})
 
 	relayRespCh := make(chan error, len(m.relays))
 
+	var wg sync.WaitGroup
 	for _, relay := range m.relays {
+		wg.Add(1)
 		go func(relay RelayEntry) {
+			defer wg.Done()
 			url := relay.GetURI(pathRegisterValidator)
 			log := log.WithField("url", url)
 
-			_, err := SendHTTPRequest(context.Background(), m.httpClientRegVal, http.MethodPost, url, ua, payload, nil)
+			_, err := SendHTTPRequest(context.Background(), m.httpClientRegVal, http.MethodPost, url, ua, body, nil)
 			relayRespCh <- err
 			if err != nil {
-				log.WithError(err).Warn("error calling registerValidator on relay")
+				m.logThrottle.warn(log.WithError(err), "error calling registerValidator on relay", relay.String())
 				return
 			}
 		}(relay)
 	}
 
+	// The response is sent on the first successful relay, the batch is done once all relays responded
+	go func() {
+		wg.Wait()
+		m.registrationBatches.release(len(body))
+	}()
+
 	go m.sendValidatorRegistrationsToRelayMonitors(payload)
 
 	for i := 0; i < len(m.relays); i++ {
 		respErr := <-relayRespCh
 		if respErr == nil {
+			m.lastSuccess.record(stepRegisterValidator, time.Now())
 			m.respondOK(w, nilResponse)
 			return
 		}
@@ -289,6 +610,22 @@ func (m *BoostService) handleRegisterValidator(w http.ResponseWriter, req *http.
 	m.respondError(w, http.StatusBadGateway, errNoSuccessfulRelayResponse.Error())
 }
 
+// checkRegistrationRate warns, throttled per source, when a source registers its validators more often than expected
+func (m *BoostService) checkRegistrationRate(log *logrus.Entry, req *http.Request, payload []types.SignedValidatorRegistration) {
+	source := registrationSource(req)
+	numValidators, perValidator := m.registrationRates.record(source, payload, time.Now())
+	if perValidator <= registrationRateMaxPerEpoch {
+		return
+	}
+	m.logThrottle.warn(log.WithFields(logrus.Fields{
+		"source":                   source,
+		"ua":                       req.Header.Get("User-Agent"),
+		"numValidators":            numValidators,
+		"registrationsPerEpoch":    perValidator,
+		"maxRegistrationsPerEpoch": registrationRateMaxPerEpoch,
+	}), "validator client registers its validators more often than once per epoch", source)
+}
+
 // handleGetHeader requests bids from the relays
 func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
@@ -303,49 +640,130 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 	})
 	log.Debug("getHeader")
 
+	// Exactly one summary line is logged per getHeader request, whatever the outcome
+	summary := newAuctionSummary(slot, parentHashHex, pubkey)
+	defer func() {
+		m.log.WithFields(summary.logFields()).Info("auction summary")
+	}()
+
+	if m.disableGetHeader {
+		m.respondError(w, http.StatusNotImplemented, errGetHeaderDisabled.Error())
+		return
+	}
+
 	_slot, err := strconv.ParseUint(slot, 10, 64)
 	if err != nil {
 		m.respondError(w, http.StatusBadRequest, errInvalidSlot.Error())
 		return
 	}
 
-	if len(pubkey) != 98 {
-		m.respondError(w, http.StatusBadRequest, errInvalidPubkey.Error())
+	normalizedPubkey, err := normalizePubkeyHex(pubkey)
+	if err != nil {
+		m.respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if normalizedPubkey != pubkey {
+		numPubkeyNormalizations := atomic.AddUint64(&m.numPubkeyNormalizations, 1)
+		log = log.WithFields(logrus.Fields{
+			"pubkey":         normalizedPubkey,
+			"originalPubkey": pubkey,
+			"ua":             req.Header.Get("User-Agent"),
+		})
+		log.WithField("numPubkeyNormalizations", numPubkeyNormalizations).Debug("normalized proposer pubkey")
+		pubkey = normalizedPubkey
+		summary.pubkey = pubkey
+	}
 
 	if len(parentHashHex) != 66 {
 		m.respondError(w, http.StatusBadRequest, errInvalidHash.Error())
 		return
 	}
 
+	// Misconfigured beacon nodes may request headers for slots which are long gone, relays can't serve those.
+	// Malformed requests are rejected above, whatever their slot.
+	if m.slotClock.known() {
+		currentSlot := m.slotClock.currentSlot(time.Now())
+		slotLog := log.WithFields(logrus.Fields{
+			"currentSlot": currentSlot,
+			"skew":        time.Since(m.slotClock.slotStart(_slot)).String(),
+		})
+		switch {
+		case currentSlot > m.getHeaderSlotTolerance && _slot < currentSlot-m.getHeaderSlotTolerance:
+			numPastSlotRequests := atomic.AddUint64(&m.numPastSlotRequests, 1)
+			slotLog.WithField("numPastSlotRequests", numPastSlotRequests).Warn("getHeader requested for a past slot, please check the clock and genesis time of the beacon node")
+			if m.strictGetHeaderSlot {
+				m.respondError(w, http.StatusBadRequest, errPastSlot.Error())
+				return
+			}
+			summary.outcome = auctionOutcomeNoBid
+			summary.pastSlot = true
+			m.respondNoBid(w, summary)
+			return
+		case _slot > currentSlot+m.getHeaderSlotTolerance:
+			numFutureSlotRequests := atomic.AddUint64(&m.numFutureSlotRequests, 1)
+			slotLog.WithField("numFutureSlotRequests", numFutureSlotRequests).Warn("getHeader requested for a far future slot, please check the clock and genesis time of the beacon node")
+		}
+	}
+
 	result := bidResp{}                           // the final response, containing the highest bid (if any)
 	relays := make(map[BlockHashHex][]RelayEntry) // relays that sent the bid for a specific blockHash
+	outcomes := getHeaderOutcomes{}               // how each of the relays responded
+	var bestValue *big.Int                        // the highest value of all valid bids, including those below the minimum bid
+	var resultWeight int                          // the weight of the relay which sent the result, to break ties
+
+	// Skip the relays which keep failing, for the time being
+	relaysToQuery, numSkipped := m.relayBreaker.selectRelays(m.relays, time.Now())
+	if numSkipped > 0 {
+		log.WithField("numSkipped", numSkipped).Debug("skipping relays which failed repeatedly")
+	}
+	summary.relaysQueried = len(relaysToQuery)
 
 	// Call the relays
 	var mu sync.Mutex
 	var wg sync.WaitGroup
-	for _, relay := range m.relays {
+	for _, relay := range relaysToQuery {
 		wg.Add(1)
 		go func(relay RelayEntry) {
 			defer wg.Done()
+
+			// Every return path below sets the outcome, which is recorded once the request is done
+			outcome := getHeaderOutcomeRejected
+			defer func() {
+				mu.Lock()
+				outcomes[outcome]++
+				mu.Unlock()
+				m.relayBreaker.record(relay.String(), outcome.isFailure(), time.Now())
+			}()
+
 			path := fmt.Sprintf("/eth/v1/builder/header/%s/%s/%s", slot, parentHashHex, pubkey)
 			url := relay.GetURI(path)
 			log := log.WithField("url", url)
 			responsePayload := new(GetHeaderResponse)
 			code, err := SendHTTPRequest(context.Background(), m.httpClientGetHeader, http.MethodGet, url, UserAgent(req.Header.Get("User-Agent")), nil, responsePayload)
+			if errors.Is(err, errEmptyResponse) {
+				// Some relays answer with an empty body or null instead of 204, which is not worth counting as an error
+				outcome = getHeaderOutcomeNoBid
+				log.WithFields(logrus.Fields{
+					"outcome":              outcome,
+					"numEmptyBidResponses": m.numEmptyBidResponses.inc(relay.String()),
+				}).Debug("relay responded with status 200 but no bid, instead of 204")
+				return
+			}
 			if err != nil {
-				log.WithError(err).Warn("error making request to relay")
+				outcome = classifyGetHeaderError(err)
+				m.logThrottle.warn(log.WithError(err).WithField("outcome", outcome), "error making request to relay", relay.String(), outcome)
 				return
 			}
 
 			if code == http.StatusNoContent {
-				log.Debug("no-content response")
+				outcome = getHeaderOutcomeNoBid
+				log.WithField("outcome", outcome).Debug("no-content response")
 				return
 			}
 
 			// Skip if invalid payload
 			if responsePayload.IsInvalid() {
+				log.WithField("outcome", outcome).Warn("invalid bid received")
 				return
 			}
 
@@ -364,7 +782,7 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 			}
 
 			// Verify the relay signature in the relay response
-			ok, err := types.VerifySignature(responsePayload.Message(), m.builderSigningDomain, relay.PublicKey[:], responsePayload.Signature())
+			ok, err := m.relayPubkeys.verifySignature(responsePayload.Message(), m.builderSigningDomain, relay.PublicKey, responsePayload.Signature())
 			if err != nil {
 				log.WithError(err).Error("error verifying relay signature")
 				return
@@ -392,14 +810,21 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 			}
 			log.Debug("bid received")
 
+			mu.Lock()
+			defer mu.Unlock()
+
+			if bestValue == nil || responsePayload.Value().Cmp(bestValue) > 0 {
+				bestValue = responsePayload.Value()
+			}
+
 			// Skip if value (fee) is lower than the minimum bid
 			if responsePayload.Value().Cmp(m.relayMinBid.BigInt()) == -1 {
-				log.Debug("ignoring bid below min-bid value")
+				outcome = getHeaderOutcomeBelowMin
+				log.WithField("outcome", outcome).Debug("ignoring bid below min-bid value")
 				return
 			}
 
-			mu.Lock()
-			defer mu.Unlock()
+			outcome = getHeaderOutcomeBid
 
 			// Remember which relays delivered which bids (multiple relays might deliver the top bid)
 			relays[BlockHashHex(blockHash)] = append(relays[BlockHashHex(blockHash)], relay)
@@ -409,9 +834,12 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 				valueDiff := responsePayload.Value().Cmp(result.response.Value())
 				if valueDiff == -1 { // current bid is less profitable than already known one
 					return
-				} else if valueDiff == 0 { // current bid is equally profitable as already known one. Use hash as tiebreaker
+				} else if valueDiff == 0 { // current bid is equally profitable as already known one. Prefer the relay with the higher weight, then use hash as tiebreaker
+					if relay.Weight < resultWeight {
+						return
+					}
 					previousBidBlockHash := result.response.BlockHash()
-					if blockHash >= previousBidBlockHash {
+					if relay.Weight == resultWeight && blockHash >= previousBidBlockHash {
 						return
 					}
 				}
@@ -422,21 +850,26 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 			result.response = *responsePayload
 			result.blockHash = blockHash
 			result.t = time.Now()
+			resultWeight = relay.Weight
 		}(relay)
 	}
 
 	// Wait for all requests to complete...
 	wg.Wait()
 
+	log = log.WithFields(outcomes.logFields())
+	summary.setRelayOutcomes(outcomes, bestValue, result.blockHash != "")
 	if result.blockHash == "" {
 		log.Info("no bid received")
-		w.WriteHeader(http.StatusNoContent)
+		m.respondNoBid(w, summary)
 		return
 	}
 
 	// Log result
 	valueEth := weiBigIntToEthBigFloat(result.response.Value())
 	result.relays = relays[BlockHashHex(result.blockHash)]
+	result.pubkey = pubkey
+	summary.chosenRelays = result.relays
 	log.WithFields(logrus.Fields{
 		"blockHash":   result.blockHash,
 		"blockNumber": result.response.BlockNumber(),
@@ -445,13 +878,30 @@ func (m *BoostService) handleGetHeader(w http.ResponseWriter, req *http.Request)
 		"relays":      strings.Join(RelayEntriesToStrings(result.relays), ", "),
 	}).Info("best bid")
 
+	// Tell the interested relay monitors about the top bid of the slot, regardless of whether the proposer will use it
+	relayPubkeys := make([]string, len(result.relays))
+	for i, relay := range result.relays {
+		relayPubkeys[i] = relay.PublicKey.String()
+	}
+	m.observeTopBid(&TopBidObserved{
+		Slot:         _slot,
+		ParentHash:   parentHashHex,
+		BlockHash:    result.blockHash,
+		Value:        result.response.Value().String(),
+		RelayPubkeys: relayPubkeys,
+	}, result.response.Value())
+
 	// Remember the bid, for future logging in case of withholding
 	bidKey := bidRespKey{slot: _slot, blockHash: result.blockHash}
-	m.bidsLock.Lock()
-	m.bids[bidKey] = result
-	m.bidsLock.Unlock()
+	if numEvicted := m.bids.add(bidKey, result); numEvicted > 0 {
+		log.WithFields(logrus.Fields{
+			"numEvicted": numEvicted,
+			"numBids":    m.bids.len(),
+		}).Warn("bid cache is full, evicted bids of previous slots")
+	}
 
 	// Return the bid
+	m.lastSuccess.record(stepGetHeader, time.Now())
 	m.respondOK(w, &result.response)
 }
 
@@ -468,10 +918,21 @@ func (m *BoostService) processBellatrixPayload(w http.ResponseWriter, req *http.
 		"parentHash": payload.Message.Body.ExecutionPayloadHeader.ParentHash.String(),
 	})
 
+	// Exactly one summary line is logged per getPayload request sent to the relays, following the auction summary
+	summary := &payloadSummary{
+		start:      time.Now(),
+		slot:       strconv.FormatUint(payload.Message.Slot, 10),
+		parentHash: payload.Message.Body.ExecutionPayloadHeader.ParentHash.String(),
+		blockHash:  payload.Message.Body.ExecutionPayloadHeader.BlockHash.String(),
+		result:     payloadResultFailed,
+	}
+	defer func() {
+		m.log.WithFields(summary.logFields()).Info("payload summary")
+	}()
+
 	bidKey := bidRespKey{slot: payload.Message.Slot, blockHash: payload.Message.Body.ExecutionPayloadHeader.BlockHash.String()}
-	m.bidsLock.Lock()
-	originalBid := m.bids[bidKey]
-	m.bidsLock.Unlock()
+	originalBid, _ := m.bids.get(bidKey)
+	summary.pubkey = originalBid.pubkey
 	if originalBid.blockHash == "" {
 		log.Error("no bid for this getPayload payload found. was getHeader called before?")
 	} else if len(originalBid.relays) == 0 {
@@ -486,10 +947,12 @@ func (m *BoostService) processBellatrixPayload(w http.ResponseWriter, req *http.
 		log.Warn("originating relay not found, sending getPayload request to all relays")
 		relays = m.relays
 	}
+	summary.relaysQueried = len(relays)
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	result := new(types.GetPayloadResponse)
+	var deliveredBy RelayEntry // the relay which delivered the payload
 	ua := UserAgent(req.Header.Get("User-Agent"))
 
 	// Prepare the request context, which will be cancelled after the first successful response from a relay
@@ -551,6 +1014,7 @@ func (m *BoostService) processBellatrixPayload(w http.ResponseWriter, req *http.
 			// Received successful response. Now cancel other requests and return immediately
 			requestCtxCancel()
 			*result = *responsePayload
+			deliveredBy = relay
 			log.Info("received payload from relay")
 		}(relay)
 	}
@@ -566,6 +1030,16 @@ func (m *BoostService) processBellatrixPayload(w http.ResponseWriter, req *http.
 		return
 	}
 
+	txs := make([][]byte, len(result.Data.Transactions))
+	for i, tx := range result.Data.Transactions {
+		txs[i] = tx
+	}
+	m.checkPayloadValue(log, deliveredBy, originalBid.response.Value(), originalBid.pubkey, common.Address(result.Data.FeeRecipient), txs)
+	m.relayDiversity.recordWin(deliveredBy, payload.Message.Slot)
+	summary.result = payloadResultDelivered
+	summary.deliveredBy = &deliveredBy
+
+	m.lastSuccess.record(stepGetPayload, time.Now())
 	m.respondOK(w, result)
 }
 
@@ -582,10 +1056,21 @@ func (m *BoostService) processCapellaPayload(w http.ResponseWriter, req *http.Re
 		"parentHash": payload.Message.Body.ExecutionPayloadHeader.ParentHash.String(),
 	})
 
+	// Exactly one summary line is logged per getPayload request sent to the relays, following the auction summary
+	summary := &payloadSummary{
+		start:      time.Now(),
+		slot:       strconv.FormatUint(uint64(payload.Message.Slot), 10),
+		parentHash: payload.Message.Body.ExecutionPayloadHeader.ParentHash.String(),
+		blockHash:  payload.Message.Body.ExecutionPayloadHeader.BlockHash.String(),
+		result:     payloadResultFailed,
+	}
+	defer func() {
+		m.log.WithFields(summary.logFields()).Info("payload summary")
+	}()
+
 	bidKey := bidRespKey{slot: uint64(payload.Message.Slot), blockHash: payload.Message.Body.ExecutionPayloadHeader.BlockHash.String()}
-	m.bidsLock.Lock()
-	originalBid := m.bids[bidKey]
-	m.bidsLock.Unlock()
+	originalBid, _ := m.bids.get(bidKey)
+	summary.pubkey = originalBid.pubkey
 	if originalBid.blockHash == "" {
 		log.Error("no bid for this getPayload payload found. was getHeader called before?")
 	} else if len(originalBid.relays) == 0 {
@@ -600,10 +1085,12 @@ func (m *BoostService) processCapellaPayload(w http.ResponseWriter, req *http.Re
 		log.Warn("originating relay not found, sending getPayload request to all relays")
 		relays = m.relays
 	}
+	summary.relaysQueried = len(relays)
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	result := new(api.VersionedExecutionPayload)
+	var deliveredBy RelayEntry // the relay which delivered the payload
 	ua := UserAgent(req.Header.Get("User-Agent"))
 
 	// Prepare the request context, which will be cancelled after the first successful response from a relay
@@ -664,6 +1151,7 @@ func (m *BoostService) processCapellaPayload(w http.ResponseWriter, req *http.Re
 			// Received successful response. Now cancel other requests and return immediately
 			requestCtxCancel()
 			*result = *responsePayload
+			deliveredBy = relay
 			log.Info("received payload from relay")
 		}(relay)
 	}
@@ -679,6 +1167,16 @@ func (m *BoostService) processCapellaPayload(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	txs := make([][]byte, len(result.Capella.Transactions))
+	for i, tx := range result.Capella.Transactions {
+		txs[i] = tx
+	}
+	m.checkPayloadValue(log, deliveredBy, originalBid.response.Value(), originalBid.pubkey, common.Address(result.Capella.FeeRecipient), txs)
+	m.relayDiversity.recordWin(deliveredBy, uint64(payload.Message.Slot))
+	summary.result = payloadResultDelivered
+	summary.deliveredBy = &deliveredBy
+
+	m.lastSuccess.record(stepGetPayload, time.Now())
 	m.respondOK(w, result)
 }
 
@@ -686,6 +1184,11 @@ func (m *BoostService) handleGetPayload(w http.ResponseWriter, req *http.Request
 	log := m.log.WithField("method", "getPayload")
 	log.Debug("getPayload")
 
+	if m.disableGetPayload {
+		m.respondError(w, http.StatusNotImplemented, errGetPayloadDisabled.Error())
+		return
+	}
+
 	// Read the body first, so we can log it later on error
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
@@ -726,7 +1229,7 @@ func (m *BoostService) CheckRelays() int {
 
 			code, err := SendHTTPRequest(context.Background(), m.httpClientGetHeader, http.MethodGet, url, "", nil, nil)
 			if err != nil {
-				log.WithError(err).Error("relay status error - request failed")
+				m.logThrottle.error(log.WithError(err), "relay status error - request failed", relay.String())
 				return
 			}
 			if code == http.StatusOK {
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"math/big"
 	"net/http"
@@ -23,6 +24,8 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/flashbots/go-boost-utils/types"
 	"github.com/flashbots/mev-boost/config"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
 )
 
@@ -125,9 +128,62 @@ func blindedBlockToExecutionPayloadCapella(signedBlindedBeaconBlock *apiv1capell
 
 func TestNewBoostServiceErrors(t *testing.T) {
 	t.Run("errors when no relays", func(t *testing.T) {
-		_, err := NewBoostService(BoostServiceOpts{testLog, ":123", []RelayEntry{}, []*url.URL{}, "0x00000000", true, types.IntToU256(0), time.Second, time.Second, time.Second, 1})
+		_, err := NewBoostService(BoostServiceOpts{
+			Log:                      testLog,
+			ListenAddr:               ":123",
+			Relays:                   []RelayEntry{},
+			RelayMonitors:            []RelayMonitorEntry{},
+			GenesisForkVersionHex:    "0x00000000",
+			RelayCheck:               true,
+			RelayMinBid:              types.IntToU256(0),
+			RequestTimeoutGetHeader:  time.Second,
+			RequestTimeoutGetPayload: time.Second,
+			RequestTimeoutRegVal:     time.Second,
+			RequestMaxRetries:        1,
+		})
 		require.Error(t, err)
 	})
+
+	t.Run("errors when getPayload is enabled while getHeader is disabled", func(t *testing.T) {
+		_, err := NewBoostService(BoostServiceOpts{
+			Log:                   testLog,
+			Relays:                []RelayEntry{newMockRelay(t).RelayEntry},
+			GenesisForkVersionHex: "0x00000000",
+			DisableGetHeader:      true,
+		})
+		require.ErrorIs(t, err, errGetPayloadWithoutHeader)
+	})
+}
+
+func TestDisabledEndpoints(t *testing.T) {
+	hash := _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7")
+	pubkey := _HexToPubkey(
+		"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+	getHeaderPath := getHeaderPath(1, hash, pubkey)
+
+	t.Run("getHeader returns 501 when disabled", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.disableGetHeader = true
+		backend.boost.disableGetPayload = true
+
+		rr := backend.request(t, http.MethodGet, getHeaderPath, nil)
+		require.Equal(t, http.StatusNotImplemented, rr.Code)
+		require.Equal(t, `{"code":501,"message":"getHeader is disabled"}`+"\n", rr.Body.String())
+		require.Equal(t, 0, backend.relays[0].GetRequestCount(getHeaderPath))
+	})
+
+	t.Run("getPayload returns 501 when disabled", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.disableGetPayload = true
+
+		rr := backend.request(t, http.MethodGet, getHeaderPath, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = backend.request(t, http.MethodPost, pathGetPayload, new(types.SignedBlindedBeaconBlock))
+		require.Equal(t, http.StatusNotImplemented, rr.Code)
+		require.Equal(t, `{"code":501,"message":"getPayload is disabled"}`+"\n", rr.Body.String())
+		require.Equal(t, 0, backend.relays[0].GetRequestCount(pathGetPayload))
+	})
 }
 
 func TestWebserver(t *testing.T) {
@@ -208,6 +264,39 @@ func TestStatus(t *testing.T) {
 		require.Equal(t, config.ForkVersion, rr.Header().Get("X-MEVBoost-ForkVersion"))
 		require.Equal(t, 0, backend.relays[0].GetRequestCount(path))
 	})
+
+	t.Run("Relay clock skew and server are reported", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		path := "/eth/v1/builder/status"
+		host := backend.relays[0].RelayEntry.URL.Host
+
+		// The status requests to the relays measure their clocks
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.True(t, strings.HasPrefix(rr.Header().Get("X-MEVBoost-Relay-Clock-Skew"), host+"="), rr.Header().Get("X-MEVBoost-Relay-Clock-Skew"))
+		require.Empty(t, rr.Header().Get("X-MEVBoost-Relay-Server"))
+
+		backend.boost.relayVersions.record(host, "relay/v0.1.0", time.Now())
+		rr = backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, host+`="relay/v0.1.0"`, rr.Header().Get("X-MEVBoost-Relay-Server"))
+	})
+
+	t.Run("Disabled methods are reported", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		path := "/eth/v1/builder/status"
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Empty(t, rr.Header().Get("X-MEVBoost-Disabled"))
+
+		backend.boost.disableGetPayload = true
+		rr = backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "getPayload", rr.Header().Get("X-MEVBoost-Disabled"))
+
+		backend.boost.disableGetHeader = true
+		rr = backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, "getHeader,getPayload", rr.Header().Get("X-MEVBoost-Disabled"))
+	})
 }
 
 func TestRegisterValidator(t *testing.T) {
@@ -231,6 +320,22 @@ func TestRegisterValidator(t *testing.T) {
 		require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
 	})
 
+	t.Run("Last success is reported on the status endpoint", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		start := time.Unix(1_700_000_000, 0)
+		backend.boost.lastSuccess = newLastSuccessTracker(start)
+
+		rr := backend.request(t, http.MethodGet, "/eth/v1/builder/status", nil)
+		require.Equal(t, "registerValidator=1700000000, getHeader=1700000000, getPayload=1700000000", rr.Header().Get("X-MEVBoost-Last-Success"))
+
+		rr = backend.request(t, http.MethodPost, path, payload)
+		require.Equal(t, http.StatusOK, rr.Code)
+		rr = backend.request(t, http.MethodGet, "/eth/v1/builder/status", nil)
+		lastSuccess := rr.Header().Get("X-MEVBoost-Last-Success")
+		require.NotContains(t, lastSuccess, "registerValidator=1700000000")
+		require.Contains(t, lastSuccess, "getHeader=1700000000, getPayload=1700000000")
+	})
+
 	t.Run("Relay error response", func(t *testing.T) {
 		backend := newTestBackend(t, 2, time.Second)
 
@@ -262,6 +367,26 @@ func TestRegisterValidator(t *testing.T) {
 		require.Equal(t, 3, backend.relays[1].GetRequestCount(path))
 	})
 
+	t.Run("Unchanged registrations are only deduplicated for relay monitors", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		monitor := newMockRelay(t)
+		monitorEntry, err := NewRelayMonitorEntry(monitor.RelayEntry.URL.String())
+		require.NoError(t, err)
+		backend.boost.relayMonitors = []RelayMonitorEntry{monitorEntry}
+		backend.boost.monitorRegistrations = newRegistrationDeduplicator(time.Hour)
+
+		rr := backend.request(t, http.MethodPost, path, payload)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Eventually(t, func() bool { return monitor.GetRequestCount(path) == 1 }, time.Second, 10*time.Millisecond)
+
+		rr = backend.request(t, http.MethodPost, path, payload)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, 2, backend.relays[0].GetRequestCount(path))
+
+		time.Sleep(50 * time.Millisecond)
+		require.Equal(t, 1, monitor.GetRequestCount(path))
+	})
+
 	t.Run("mev-boost relay timeout works with slow relay", func(t *testing.T) {
 		backend := newTestBackend(t, 1, 150*time.Millisecond) // 10ms max
 		rr := backend.request(t, http.MethodPost, path, payload)
@@ -274,6 +399,28 @@ func TestRegisterValidator(t *testing.T) {
 		require.Equal(t, http.StatusBadGateway, rr.Code)
 		require.Equal(t, 2, backend.relays[0].GetRequestCount(path))
 	})
+
+	t.Run("Too many batches in flight are rejected with 503", func(t *testing.T) {
+		backend := newTestBackend(t, 1, 1500*time.Millisecond)
+		backend.boost.registrationBatches = newRegistrationBatches(1)
+		require.True(t, backend.boost.registrationBatches.acquire())
+
+		rr := backend.request(t, http.MethodPost, path, payload)
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		require.Equal(t, "2", rr.Header().Get("Retry-After"))
+		require.Equal(t, 0, backend.relays[0].GetRequestCount(path))
+
+		backend.boost.registrationBatches.release(0)
+		rr = backend.request(t, http.MethodPost, path, payload)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, 1, backend.relays[0].GetRequestCount(path))
+
+		// The batch is released once all relays responded
+		require.Eventually(t, func() bool {
+			numInFlight, bufferedBytes := backend.boost.registrationBatches.stats()
+			return numInFlight == 0 && bufferedBytes == 0
+		}, time.Second, 10*time.Millisecond)
+	})
 }
 
 func getHeaderPath(slot uint64, parentHash types.Hash, pubkey types.PublicKey) string {
@@ -375,16 +522,141 @@ func TestGetHeader(t *testing.T) {
 		require.Equal(t, 0, backend.relays[0].GetRequestCount(path))
 	})
 
+	t.Run("Past and far future slots", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.getHeaderSlotTolerance = 1
+		// Genesis in the middle of a slot, so that the current slot is 100
+		backend.boost.slotClock = slotClock{genesisTime: uint64(time.Now().Unix()) - 100*secondsPerSlot - 6}
+
+		for _, slot := range []uint64{99, 100, 101} {
+			rr := backend.request(t, http.MethodGet, getHeaderPath(slot, hash, pubkey), nil)
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		}
+
+		pastSlotPath := getHeaderPath(98, hash, pubkey)
+		rr := backend.request(t, http.MethodGet, pastSlotPath, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		require.Equal(t, 0, backend.relays[0].GetRequestCount(pastSlotPath))
+		require.Equal(t, uint64(1), backend.boost.numPastSlotRequests)
+
+		backend.boost.strictGetHeaderSlot = true
+		rr = backend.request(t, http.MethodGet, pastSlotPath, nil)
+		require.Equal(t, `{"code":400,"message":"slot is in the past"}`+"\n", rr.Body.String())
+		require.Equal(t, 0, backend.relays[0].GetRequestCount(pastSlotPath))
+		require.Equal(t, uint64(2), backend.boost.numPastSlotRequests)
+
+		// Malformed requests for past slots are rejected as such
+		backend.boost.strictGetHeaderSlot = false
+		rr = backend.request(t, http.MethodGet, fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 98, hash.String(), "0x1"), nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+		require.Equal(t, uint64(2), backend.boost.numPastSlotRequests)
+
+		// Requests for far future slots are only counted
+		futureSlotPath := getHeaderPath(102, hash, pubkey)
+		rr = backend.request(t, http.MethodGet, futureSlotPath, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, 1, backend.relays[0].GetRequestCount(futureSlotPath))
+		require.Equal(t, uint64(1), backend.boost.numFutureSlotRequests)
+	})
+
 	t.Run("Invalid pubkey length", func(t *testing.T) {
 		invalidPubkeyPath := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 1, hash.String(), "0x1")
 
 		backend := newTestBackend(t, 1, time.Second)
 		rr := backend.request(t, http.MethodGet, invalidPubkeyPath, nil)
-		require.Equal(t, `{"code":400,"message":"invalid pubkey"}`+"\n", rr.Body.String())
+		require.Equal(t, `{"code":400,"message":"invalid pubkey: expected 96 hex characters, got 1"}`+"\n", rr.Body.String())
 		require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
 		require.Equal(t, 0, backend.relays[0].GetRequestCount(path))
 	})
 
+	t.Run("Non-canonical pubkey is normalized", func(t *testing.T) {
+		for _, variant := range []string{
+			strings.TrimPrefix(pubkey.String(), "0x"),
+			strings.ToUpper(pubkey.String()[2:]),
+			"0x" + strings.ToUpper(pubkey.String()[2:]),
+			"0X" + strings.ToUpper(pubkey.String()[2:]),
+		} {
+			backend := newTestBackend(t, 1, time.Second)
+			variantPath := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 1, hash.String(), variant)
+			rr := backend.request(t, http.MethodGet, variantPath, nil)
+			require.Equal(t, http.StatusOK, rr.Code, variant)
+			require.Equal(t, 1, backend.relays[0].GetRequestCount(path), variant)
+			require.Equal(t, uint64(1), backend.boost.numPubkeyNormalizations, variant)
+		}
+
+		backend := newTestBackend(t, 1, time.Second)
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, uint64(0), backend.boost.numPubkeyNormalizations)
+	})
+
+	t.Run("Top bid of the slot is sent to opted-in relay monitors once the slot is over", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		// Slot 1 is over in one to two seconds
+		backend.boost.slotClock = slotClock{genesisTime: uint64(time.Now().Unix()) - 2*secondsPerSlot + 2}
+
+		topBids := make(chan []byte, 10)
+		monitor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, pathTopBid, r.URL.Path)
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			topBids <- body
+		}))
+		defer monitor.Close()
+		monitorEntry, err := NewRelayMonitorEntry(monitor.URL)
+		require.NoError(t, err)
+		backend.boost.relayMonitorsTopBid = []RelayMonitorEntry{monitorEntry}
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		// A later getHeader of the slot with a higher bid
+		backend.relays[0].GetHeaderResponse = backend.relays[0].MakeGetHeaderResponse(
+			23456,
+			"0xa38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+			hash.String(),
+			"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+			consensusspec.DataVersionBellatrix,
+		)
+		rr = backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Empty(t, topBids)
+
+		var body []byte
+		require.Eventually(t, func() bool {
+			select {
+			case body = <-topBids:
+				return true
+			default:
+				return false
+			}
+		}, 3*time.Second, 10*time.Millisecond)
+
+		topBid := new(TopBidObserved)
+		require.NoError(t, json.Unmarshal(body, topBid))
+		require.Equal(t, uint64(1), topBid.Slot)
+		require.Equal(t, hash.String(), topBid.ParentHash)
+		require.Equal(t, "0xa38385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", topBid.BlockHash)
+		require.Equal(t, "23456", topBid.Value)
+		require.Equal(t, []string{backend.relays[0].RelayEntry.PublicKey.String()}, topBid.RelayPubkeys)
+
+		time.Sleep(50 * time.Millisecond)
+		require.Empty(t, topBids)
+	})
+
+	t.Run("Top bid of a far future slot is not tracked", func(t *testing.T) {
+		backend := newTestBackend(t, 1, time.Second)
+		backend.boost.slotClock = slotClock{genesisTime: uint64(time.Now().Unix())}
+		backend.boost.getHeaderSlotTolerance = 1
+		monitorEntry, err := NewRelayMonitorEntry("http://localhost:1")
+		require.NoError(t, err)
+		backend.boost.relayMonitorsTopBid = []RelayMonitorEntry{monitorEntry}
+
+		rr := backend.request(t, http.MethodGet, getHeaderPath(1_000_000, hash, pubkey), nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Empty(t, backend.boost.topBids.bids)
+	})
+
 	t.Run("Invalid hash length", func(t *testing.T) {
 		invalidSlotPath := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 1, "0x1", pubkey.String())
 
@@ -403,6 +675,56 @@ func TestGetHeader(t *testing.T) {
 		require.Equal(t, http.StatusNoContent, rr.Code)
 		require.Equal(t, 0, backend.relays[0].GetRequestCount(path))
 	})
+
+	t.Run("No-bid response is not counted as an error", func(t *testing.T) {
+		backend := newTestBackend(t, 2, time.Second)
+		logger, hook := logrustest.NewNullLogger()
+		backend.boost.log = logrus.NewEntry(logger)
+
+		backend.relays[0].handlerOverrideGetHeader = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		backend.relays[1].handlerOverrideGetHeader = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+
+		entries := logEntriesWithMessage(hook, "no bid received")
+		require.Len(t, entries, 1)
+		entry := entries[0]
+		require.Equal(t, 2, entry.Data["numNoBids"])
+		require.Equal(t, 0, entry.Data["numErrors"])
+		require.Equal(t, 0, entry.Data["numTimeouts"])
+		require.Equal(t, 0, entry.Data["numRejected"])
+		for _, e := range hook.AllEntries() {
+			require.GreaterOrEqual(t, e.Level, logrus.InfoLevel, "unexpected log entry: %s", e.Message)
+		}
+	})
+
+	t.Run("Empty response with status 200 is counted as no-bid", func(t *testing.T) {
+		for _, body := range []string{"", "null", "{}"} {
+			backend := newTestBackend(t, 1, time.Second)
+			logger, hook := logrustest.NewNullLogger()
+			backend.boost.log = logrus.NewEntry(logger)
+
+			backend.relays[0].handlerOverrideGetHeader = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(body))
+			}
+
+			rr := backend.request(t, http.MethodGet, path, nil)
+			require.Equal(t, http.StatusNoContent, rr.Code)
+
+			entries := logEntriesWithMessage(hook, "no bid received")
+			require.Len(t, entries, 1)
+			require.Equal(t, 1, entries[0].Data["numNoBids"], body)
+			require.Equal(t, 0, entries[0].Data["numErrors"], body)
+			require.Equal(t, 0, entries[0].Data["numTruncated"], body)
+			require.Equal(t, 0, entries[0].Data["numRejected"], body)
+		}
+	})
 }
 
 func TestGetHeaderBids(t *testing.T) {
@@ -507,6 +829,33 @@ func TestGetHeaderBids(t *testing.T) {
 		require.Equal(t, "0xa18385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", resp.BlockHash())
 	})
 
+	t.Run("Use header of relay with highest weight if same value", func(t *testing.T) {
+		backend := newTestBackend(t, 3, time.Second)
+		backend.boost.relays[0].Weight = 5
+		backend.boost.relays[2].Weight = 10
+
+		// Relay 0 and 2 bid the same value, relay 1 bids less
+		for i, value := range []uint64{12345, 12344, 12345} {
+			backend.relays[i].GetHeaderResponse = backend.relays[i].MakeGetHeaderResponse(
+				value,
+				fmt.Sprintf("0xa%d8385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", i),
+				"0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7",
+				"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249",
+				consensusspec.DataVersionBellatrix,
+			)
+		}
+
+		rr := backend.request(t, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		// The bid of relay 2 wins by weight, despite its higher block hash
+		resp := new(GetHeaderResponse)
+		err := json.Unmarshal(rr.Body.Bytes(), resp)
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(12345), resp.Value())
+		require.Equal(t, "0xa28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7", resp.BlockHash())
+	})
+
 	t.Run("Respect minimum bid cutoff", func(t *testing.T) {
 		// Create backend and register relay.
 		backend := newTestBackend(t, 1, time.Second)
@@ -978,3 +1327,79 @@ func TestGetPayloadToOriginRelayOnly(t *testing.T) {
 	require.Equal(t, 1, backend.relays[0].GetRequestCount(getPayloadPath))
 	require.Equal(t, 0, backend.relays[1].GetRequestCount(getPayloadPath))
 }
+
+func TestErrorResponsesDoNotLeakRelayDetails(t *testing.T) {
+	secret := "relay-secret-token"
+	hash := _HexToHash("0xe28385e7bd68df656cd0042b74b69c3104b5356ed1f20eb69f1f925df47a3ab7")
+	pubkey := _HexToPubkey(
+		"0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+	payload := types.SignedBlindedBeaconBlock{
+		Message: &types.BlindedBeaconBlock{
+			Slot: 1,
+			Body: &types.BlindedBeaconBlockBody{
+				Eth1Data:               &types.Eth1Data{},
+				SyncAggregate:          &types.SyncAggregate{},
+				ExecutionPayloadHeader: &types.ExecutionPayloadHeader{BlockHash: hash},
+			},
+		},
+	}
+	registrations := []types.SignedValidatorRegistration{{
+		Message: &types.RegisterValidatorRequestMessage{Pubkey: pubkey},
+	}}
+
+	// newFailingBackend returns a backend whose relays carry a secret in their URL and echo it in their errors
+	newFailingBackend := func(t *testing.T) *testBackend {
+		t.Helper()
+		backend := newTestBackend(t, 2, time.Second)
+		backend.boost.relayCheck = true
+		failing := func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "internal error, token="+secret+" url="+r.URL.String(), http.StatusInternalServerError)
+		}
+		for _, relay := range backend.relays {
+			relay.RelayEntry.URL.RawQuery = "token=" + secret
+			relay.handlerOverrideGetHeader = failing
+			relay.handlerOverrideGetPayload = failing
+			relay.handlerOverrideRegisterValidator = failing
+		}
+		return backend
+	}
+
+	requireNoRelayDetails := func(t *testing.T, backend *testBackend, rr *httptest.ResponseRecorder) {
+		t.Helper()
+		body := rr.Body.String()
+		require.NotContains(t, body, secret)
+		for _, relay := range backend.relays {
+			require.NotContains(t, body, relay.RelayEntry.URL.Host)
+		}
+	}
+
+	requests := []struct {
+		name    string
+		method  string
+		path    string
+		payload any
+	}{
+		{"getHeader", http.MethodGet, getHeaderPath(1, hash, pubkey), nil},
+		{"getPayload", http.MethodPost, pathGetPayload, payload},
+		{"registerValidator", http.MethodPost, pathRegisterValidator, registrations},
+		{"status", http.MethodGet, pathStatus, nil},
+	}
+
+	for _, r := range requests {
+		t.Run(r.name+" with relay errors", func(t *testing.T) {
+			backend := newFailingBackend(t)
+			rr := backend.request(t, r.method, r.path, r.payload)
+			requireNoRelayDetails(t, backend, rr)
+		})
+
+		t.Run(r.name+" with unreachable relays", func(t *testing.T) {
+			backend := newFailingBackend(t)
+			for _, relay := range backend.relays {
+				relay.Server.Close()
+			}
+			rr := backend.request(t, r.method, r.path, r.payload)
+			require.NotEqual(t, http.StatusOK, rr.Code)
+			requireNoRelayDetails(t, backend, rr)
+		})
+	}
+}
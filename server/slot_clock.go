@@ -0,0 +1,27 @@
+package server
+
+import "time"
+
+const secondsPerSlot = 12
+
+// slotClock maps wall clock time to beacon chain slots. A zero genesis time means the genesis is unknown.
+type slotClock struct {
+	genesisTime uint64 // unix timestamp
+}
+
+func (c slotClock) known() bool {
+	return c.genesisTime > 0
+}
+
+// slotStart returns the time at which the given slot starts
+func (c slotClock) slotStart(slot uint64) time.Time {
+	return time.Unix(int64(c.genesisTime+slot*secondsPerSlot), 0)
+}
+
+// currentSlot returns the slot at the given time, which is 0 before genesis
+func (c slotClock) currentSlot(now time.Time) uint64 {
+	if now.Unix() < int64(c.genesisTime) {
+		return 0
+	}
+	return (uint64(now.Unix()) - c.genesisTime) / secondsPerSlot
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotClock(t *testing.T) {
+	c := slotClock{genesisTime: 1606824023}
+	require.True(t, c.known())
+	require.False(t, slotClock{}.known())
+
+	require.Equal(t, time.Unix(1606824023, 0), c.slotStart(0))
+	require.Equal(t, time.Unix(1606824023+12*100, 0), c.slotStart(100))
+
+	require.Equal(t, uint64(0), c.currentSlot(time.Unix(1606824000, 0)))
+	require.Equal(t, uint64(0), c.currentSlot(time.Unix(1606824023+11, 0)))
+	require.Equal(t, uint64(1), c.currentSlot(time.Unix(1606824023+12, 0)))
+	require.Equal(t, uint64(100), c.currentSlot(c.slotStart(100).Add(6*time.Second)))
+}
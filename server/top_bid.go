@@ -0,0 +1,57 @@
+package server
+
+import (
+	"math/big"
+	"sync"
+)
+
+// topBidTracker keeps the highest bid of every slot which is still running, to send it to the relay monitors once the
+// slot is over. A slot may have several getHeader requests, e.g. retries of the beacon node, with different bids.
+type topBidTracker struct {
+	mu           sync.Mutex
+	bids         map[uint64]topBid // by slot
+	lastSentSlot uint64            // bids for this slot and earlier ones are too late, their top bid was already sent
+}
+
+type topBid struct {
+	observed *TopBidObserved
+	value    *big.Int
+}
+
+func newTopBidTracker() *topBidTracker {
+	return &topBidTracker{
+		bids: make(map[uint64]topBid),
+	}
+}
+
+// observe records a bid of the slot, and returns whether it is the first bid of the slot, i.e. whether the top bid of
+// the slot must be sent once the slot is over. Ties go to the earlier bid.
+func (t *topBidTracker) observe(bid *TopBidObserved, value *big.Int) (first bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.lastSentSlot > 0 && bid.Slot <= t.lastSentSlot {
+		return false
+	}
+	current, ok := t.bids[bid.Slot]
+	if !ok || value.Cmp(current.value) > 0 {
+		t.bids[bid.Slot] = topBid{observed: bid, value: value}
+	}
+	return !ok
+}
+
+// take removes and returns the top bid of the slot, or nil if it was already taken
+func (t *topBidTracker) take(slot uint64) *TopBidObserved {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bid, ok := t.bids[slot]
+	if !ok {
+		return nil
+	}
+	delete(t.bids, slot)
+	if slot > t.lastSentSlot {
+		t.lastSentSlot = slot
+	}
+	return bid.observed
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopBidTracker(t *testing.T) {
+	tracker := newTopBidTracker()
+	bid := func(slot uint64, blockHash string) *TopBidObserved {
+		return &TopBidObserved{Slot: slot, BlockHash: blockHash}
+	}
+
+	// The first bid of a slot schedules the send, the highest bid of the slot is sent
+	require.True(t, tracker.observe(bid(1, "0x01"), big.NewInt(100)))
+	require.False(t, tracker.observe(bid(1, "0x02"), big.NewInt(200)))
+	require.False(t, tracker.observe(bid(1, "0x03"), big.NewInt(150)))
+	require.False(t, tracker.observe(bid(1, "0x04"), big.NewInt(200)))
+	require.True(t, tracker.observe(bid(2, "0x05"), big.NewInt(50)))
+
+	require.Equal(t, "0x02", tracker.take(1).BlockHash)
+	require.Nil(t, tracker.take(1))
+
+	// Bids of a slot which was already sent are ignored
+	require.False(t, tracker.observe(bid(1, "0x06"), big.NewInt(300)))
+	require.Nil(t, tracker.take(1))
+
+	require.Equal(t, "0x05", tracker.take(2).BlockHash)
+}
@@ -3,14 +3,17 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math/big"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/capella"
@@ -29,6 +32,8 @@ var (
 	errInvalidForkVersion = errors.New("invalid fork version")
 	errInvalidTransaction = errors.New("invalid transaction")
 	errMaxRetriesExceeded = errors.New("max retries exceeded")
+	errTruncatedResponse  = errors.New("truncated response body")
+	errEmptyResponse      = errors.New("empty response body")
 )
 
 // UserAgent is a custom string type to avoid confusing url + userAgent parameters in SendHTTPRequest
@@ -37,28 +42,40 @@ type UserAgent string
 // BlockHashHex is a hex-string representation of a block hash
 type BlockHashHex string
 
-// SendHTTPRequest - prepare and send HTTP request, marshaling the payload if any, and decoding the response if dst is set
-func SendHTTPRequest(ctx context.Context, client http.Client, method, url string, userAgent UserAgent, payload, dst any) (code int, err error) {
-	var req *http.Request
-
-	if payload == nil {
-		req, err = http.NewRequestWithContext(ctx, method, url, nil)
-	} else {
-		payloadBytes, err2 := json.Marshal(payload)
-		if err2 != nil {
-			return 0, fmt.Errorf("could not marshal request: %w", err2)
+// newOutboundRequest prepares a request to a relay or relay monitor with the body, Content-Type and User-Agent. The
+// per-relay request headers are not set here, relayHeaderTransport adds them to the requests to each relay.
+func newOutboundRequest(ctx context.Context, method, url string, userAgent UserAgent, payload any) (*http.Request, error) {
+	var body io.Reader
+	if raw, ok := payload.(json.RawMessage); ok {
+		// already encoded, e.g. once for all relays
+		body = bytes.NewReader(raw)
+	} else if payload != nil {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal request: %w", err)
 		}
-		req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payloadBytes))
-
-		// Set headers
-		req.Header.Add("Content-Type", "application/json")
+		body = bytes.NewReader(payloadBytes)
 	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return 0, fmt.Errorf("could not prepare request: %w", err)
+		return nil, fmt.Errorf("could not prepare request: %w", err)
 	}
 
-	// Set user agent
+	// Set headers
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	req.Header.Set("User-Agent", strings.TrimSpace(fmt.Sprintf("mev-boost/%s %s", config.Version, userAgent)))
+	return req, nil
+}
+
+// SendHTTPRequest - prepare and send HTTP request, marshaling the payload if any, and decoding the response if dst is set
+func SendHTTPRequest(ctx context.Context, client http.Client, method, url string, userAgent UserAgent, payload, dst any) (code int, err error) {
+	req, err := newOutboundRequest(ctx, method, url, userAgent, payload)
+	if err != nil {
+		return 0, err
+	}
 
 	// Execute request
 	resp, err := client.Do(req)
@@ -81,11 +98,25 @@ func SendHTTPRequest(ctx context.Context, client http.Client, method, url string
 
 	if dst != nil {
 		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return resp.StatusCode, fmt.Errorf("%w: read %d of %d bytes", errTruncatedResponse, len(bodyBytes), resp.ContentLength)
+		} else if err != nil {
 			return resp.StatusCode, fmt.Errorf("could not read response body: %w", err)
 		}
+		if resp.ContentLength >= 0 && int64(len(bodyBytes)) != resp.ContentLength {
+			return resp.StatusCode, fmt.Errorf("%w: content length mismatch, read %d of %d bytes", errTruncatedResponse, len(bodyBytes), resp.ContentLength)
+		}
+
+		if isEmptyJSONBody(bodyBytes) {
+			return resp.StatusCode, errEmptyResponse
+		}
 
 		if err := json.Unmarshal(bodyBytes, dst); err != nil {
+			// A body which ends in the middle of the JSON document was cut off, e.g. by a proxy
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) && syntaxErr.Offset == int64(len(bodyBytes)) {
+				return resp.StatusCode, fmt.Errorf("%w: %s after %d bytes", errTruncatedResponse, err.Error(), len(bodyBytes))
+			}
 			return resp.StatusCode, fmt.Errorf("could not unmarshal response %s: %w", string(bodyBytes), err)
 		}
 	}
@@ -93,7 +124,18 @@ func SendHTTPRequest(ctx context.Context, client http.Client, method, url string
 	return resp.StatusCode, nil
 }
 
-// SendHTTPRequestWithRetries - prepare and send HTTP request, retrying the request if within the client timeout
+// isEmptyJSONBody returns whether the body is empty, null or an empty JSON object, which some relays send instead
+// of a 204 response
+func isEmptyJSONBody(body []byte) bool {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || bytes.Equal(body, []byte("null")) {
+		return true
+	}
+	return len(body) >= 2 && body[0] == '{' && body[len(body)-1] == '}' && len(bytes.TrimSpace(body[1:len(body)-1])) == 0
+}
+
+// SendHTTPRequestWithRetries - prepare and send HTTP request, retrying the request if within the client timeout.
+// The first truncated response is retried immediately, without counting against maxRetries.
 func SendHTTPRequestWithRetries(ctx context.Context, client http.Client, method, url string, userAgent UserAgent, payload, dst any, maxRetries int, log *logrus.Entry) (code int, err error) {
 	var requestCtx context.Context
 	var cancel context.CancelFunc
@@ -106,6 +148,7 @@ func SendHTTPRequestWithRetries(ctx context.Context, client http.Client, method,
 	defer cancel()
 
 	attempts := 0
+	retriedTruncated := false
 	for {
 		attempts++
 		if requestCtx.Err() != nil {
@@ -116,6 +159,12 @@ func SendHTTPRequestWithRetries(ctx context.Context, client http.Client, method,
 		}
 
 		code, err = SendHTTPRequest(ctx, client, method, url, userAgent, payload, dst)
+		if errors.Is(err, errTruncatedResponse) && !retriedTruncated {
+			retriedTruncated = true
+			attempts--
+			log.WithError(err).Warn("truncated response from relay, retrying immediately")
+			continue
+		}
 		if err != nil {
 			log.WithError(err).Warn("error making request to relay, retrying")
 			time.Sleep(100 * time.Millisecond) // note: this timeout is only applied between retries, it does not delay the initial request!
@@ -148,6 +197,22 @@ func DecodeJSON(r io.Reader, dst any) error {
 	return nil
 }
 
+// normalizePubkeyHex returns the canonical lowercase 0x-prefixed form of a hex-encoded BLS public key.
+// Validator clients have been seen sending uppercase hex and omitting the 0x prefix.
+func normalizePubkeyHex(pubkey string) (string, error) {
+	pubkeyHex := pubkey
+	if strings.HasPrefix(pubkeyHex, "0x") || strings.HasPrefix(pubkeyHex, "0X") {
+		pubkeyHex = pubkeyHex[2:]
+	}
+	if len(pubkeyHex) != 2*len(boostTypes.PublicKey{}) {
+		return "", fmt.Errorf("%w: expected %d hex characters, got %d", errInvalidPubkey, 2*len(boostTypes.PublicKey{}), len(pubkeyHex))
+	}
+	if _, err := hex.DecodeString(pubkeyHex); err != nil {
+		return "", fmt.Errorf("%w: not a hex string", errInvalidPubkey)
+	}
+	return "0x" + strings.ToLower(pubkeyHex), nil
+}
+
 // GetURI returns the full request URI with scheme, host, path and args.
 func GetURI(url *url.URL, path string) string {
 	u2 := *url
@@ -162,6 +227,7 @@ type bidResp struct {
 	response  GetHeaderResponse
 	blockHash string
 	relays    []RelayEntry
+	pubkey    string // the proposer which requested the bid
 }
 
 // bidRespKey is used as key for the bids cache
@@ -170,6 +236,64 @@ type bidRespKey struct {
 	blockHash string
 }
 
+// getHeaderOutcome classifies how a single relay responded to a getHeader request
+type getHeaderOutcome string
+
+const (
+	getHeaderOutcomeBid       getHeaderOutcome = "bid"       // a valid bid was received
+	getHeaderOutcomeNoBid     getHeaderOutcome = "no-bid"    // the relay has no bid (204), which is healthy behavior
+	getHeaderOutcomeError     getHeaderOutcome = "error"     // the request failed or the relay returned an error response
+	getHeaderOutcomeTimeout   getHeaderOutcome = "timeout"   // the relay did not respond in time
+	getHeaderOutcomeRejected  getHeaderOutcome = "rejected"  // a bid was received but did not pass validation
+	getHeaderOutcomeBelowMin  getHeaderOutcome = "below-min" // a valid bid was received but its value is below the minimum bid
+	getHeaderOutcomeTruncated getHeaderOutcome = "truncated" // the response body was cut off
+)
+
+// getHeaderOutcomes counts the outcomes of all relay requests for a single getHeader call
+type getHeaderOutcomes map[getHeaderOutcome]int
+
+func (o getHeaderOutcomes) logFields() logrus.Fields {
+	return logrus.Fields{
+		"numBids":      o[getHeaderOutcomeBid],
+		"numNoBids":    o[getHeaderOutcomeNoBid],
+		"numErrors":    o[getHeaderOutcomeError],
+		"numTimeouts":  o[getHeaderOutcomeTimeout],
+		"numRejected":  o[getHeaderOutcomeRejected],
+		"numBelowMin":  o[getHeaderOutcomeBelowMin],
+		"numTruncated": o[getHeaderOutcomeTruncated],
+	}
+}
+
+// relayCounter counts events per relay
+type relayCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+func newRelayCounter() *relayCounter {
+	return &relayCounter{counts: make(map[string]uint64)}
+}
+
+// inc increments the count of the relay and returns the new count
+func (c *relayCounter) inc(relay string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[relay]++
+	return c.counts[relay]
+}
+
+// classifyGetHeaderError distinguishes relay timeouts and truncated responses from other request errors
+func classifyGetHeaderError(err error) getHeaderOutcome {
+	if errors.Is(err, errTruncatedResponse) {
+		return getHeaderOutcomeTruncated
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return getHeaderOutcomeTimeout
+	}
+	return getHeaderOutcomeError
+}
+
 func httpClientDisallowRedirects(req *http.Request, via []*http.Request) error {
 	return http.ErrUseLastResponse
 }
@@ -9,7 +9,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/capella"
 	"github.com/flashbots/mev-boost/config"
@@ -24,6 +26,68 @@ func TestMakePostRequest(t *testing.T) {
 	require.Equal(t, 0, code)
 }
 
+func TestNewOutboundRequest(t *testing.T) {
+	userAgent := "mev-boost/" + config.Version
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		userAgent UserAgent
+		payload   any
+		headers   http.Header
+	}{
+		{
+			name:      "getHeader",
+			method:    http.MethodGet,
+			path:      "/eth/v1/builder/header/1/0x00/0x00",
+			userAgent: "Lighthouse/v3.4.0",
+			headers:   http.Header{"User-Agent": {userAgent + " Lighthouse/v3.4.0"}},
+		},
+		{
+			name:      "getPayload",
+			method:    http.MethodPost,
+			path:      pathGetPayload,
+			userAgent: "Lighthouse/v3.4.0",
+			payload:   struct{}{},
+			headers: http.Header{
+				"Content-Type": {"application/json"},
+				"User-Agent":   {userAgent + " Lighthouse/v3.4.0"},
+			},
+		},
+		{
+			name:    "registerValidator",
+			method:  http.MethodPost,
+			path:    pathRegisterValidator,
+			payload: []struct{}{},
+			headers: http.Header{
+				"Content-Type": {"application/json"},
+				"User-Agent":   {userAgent},
+			},
+		},
+		{
+			name:    "status",
+			method:  http.MethodGet,
+			path:    pathStatus,
+			headers: http.Header{"User-Agent": {userAgent}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := newOutboundRequest(context.Background(), tt.method, "http://localhost"+tt.path, tt.userAgent, tt.payload)
+			require.NoError(t, err)
+			require.Equal(t, tt.method, req.Method)
+			require.Equal(t, tt.path, req.URL.Path)
+			require.Equal(t, tt.headers, req.Header)
+		})
+	}
+
+	t.Run("invalid payload", func(t *testing.T) {
+		_, err := newOutboundRequest(context.Background(), http.MethodPost, "http://localhost", "", make(chan bool))
+		require.Error(t, err)
+	})
+}
+
 func TestDecodeJSON(t *testing.T) {
 	// test disallows unknown fields
 	var x struct {
@@ -86,6 +150,91 @@ func TestSendHTTPRequestGzip(t *testing.T) {
 	require.Equal(t, "test-message", resp.Msg)
 }
 
+func TestSendHTTPRequestTruncated(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		expectedError error
+	}{
+		{
+			name: "body shorter than content length",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "100")
+				_, _ = w.Write([]byte(`{ "msg": "test-message" }`))
+			},
+			expectedError: errTruncatedResponse,
+		},
+		{
+			name: "JSON cut off without content length",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{ "msg": "test-mes`))
+				w.(http.Flusher).Flush() //nolint:forcetypeassert
+			},
+			expectedError: errTruncatedResponse,
+		},
+		{
+			name: "invalid JSON is not truncated",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{ "msg": test-message }`))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.handler)
+			defer ts.Close()
+
+			resp := struct{ Msg string }{}
+			_, err := SendHTTPRequest(context.Background(), *http.DefaultClient, http.MethodGet, ts.URL, "", nil, &resp)
+			require.Error(t, err)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				require.Equal(t, getHeaderOutcomeTruncated, classifyGetHeaderError(err))
+			} else {
+				require.NotErrorIs(t, err, errTruncatedResponse)
+			}
+		})
+	}
+
+	t.Run("truncated response is retried immediately", func(t *testing.T) {
+		numRequests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			numRequests++
+			if numRequests == 1 {
+				w.Header().Set("Content-Length", "100")
+			}
+			_, _ = w.Write([]byte(`{ "msg": "test-message" }`))
+		}))
+		defer ts.Close()
+
+		// A single attempt is allowed, the retry of the truncated response is not counted
+		resp := struct{ Msg string }{}
+		code, err := SendHTTPRequestWithRetries(context.Background(), *http.DefaultClient, http.MethodGet, ts.URL, "", nil, &resp, 1, testLog)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, "test-message", resp.Msg)
+		require.Equal(t, 2, numRequests)
+	})
+}
+
+func TestSendHTTPRequestEmpty(t *testing.T) {
+	for _, body := range []string{"", "null", "{}", " { }\n"} {
+		t.Run(fmt.Sprintf("%q", body), func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(body))
+			}))
+			defer ts.Close()
+
+			resp := struct{ Msg string }{}
+			code, err := SendHTTPRequest(context.Background(), *http.DefaultClient, http.MethodGet, ts.URL, "", nil, &resp)
+			require.ErrorIs(t, err, errEmptyResponse)
+			require.NotErrorIs(t, err, errTruncatedResponse)
+			require.Equal(t, http.StatusOK, code)
+		})
+	}
+}
+
 func TestWeiBigIntToEthBigFloat(t *testing.T) {
 	// test with valid input
 	i := big.NewInt(1)
@@ -109,3 +258,51 @@ func TestCapellaComputeBlockHash(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "0x08751ea2076d3ecc606231495a90ba91a66a9b8fb1a2b76c333f1957a1c667c3", hash.String())
 }
+
+func TestClassifyGetHeaderError(t *testing.T) {
+	t.Run("client timeout is a timeout", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+		}))
+		defer ts.Close()
+
+		client := http.Client{Timeout: 10 * time.Millisecond}
+		_, err := SendHTTPRequest(context.Background(), client, http.MethodGet, ts.URL, "", nil, nil)
+		require.Error(t, err)
+		require.Equal(t, getHeaderOutcomeTimeout, classifyGetHeaderError(err))
+	})
+
+	t.Run("error response is an error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		_, err := SendHTTPRequest(context.Background(), *http.DefaultClient, http.MethodGet, ts.URL, "", nil, nil)
+		require.Error(t, err)
+		require.Equal(t, getHeaderOutcomeError, classifyGetHeaderError(err))
+	})
+}
+
+func TestNormalizePubkeyHex(t *testing.T) {
+	canonical := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+
+	for _, variant := range []string{
+		canonical,
+		canonical[2:],
+		"0x" + strings.ToUpper(canonical[2:]),
+		"0X" + strings.ToUpper(canonical[2:]),
+		strings.ToUpper(canonical[2:]),
+	} {
+		normalized, err := normalizePubkeyHex(variant)
+		require.NoError(t, err, variant)
+		require.Equal(t, canonical, normalized, variant)
+	}
+
+	_, err := normalizePubkeyHex(canonical[:96])
+	require.ErrorIs(t, err, errInvalidPubkey)
+	require.EqualError(t, err, "invalid pubkey: expected 96 hex characters, got 94")
+
+	_, err = normalizePubkeyHex("0x" + strings.Repeat("z", 96))
+	require.ErrorIs(t, err, errInvalidPubkey)
+}